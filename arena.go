@@ -0,0 +1,22 @@
+// Package main implements a Lox language interpreter
+package main
+
+// arenaChunkSize is the number of values allocated at once by an arena.
+const arenaChunkSize = 256
+
+// arena hands out pointers into preallocated chunks of T instead of
+// allocating every value on its own. Tokens and AST nodes live as long as
+// the program they belong to, so grouping them cuts down GC pressure when
+// parsing large sources.
+type arena[T any] struct {
+	chunk []T // Current chunk; a new one is started once it fills up
+}
+
+// alloc copies value into the arena and returns a pointer to the copy.
+func (a *arena[T]) alloc(value T) *T {
+	if len(a.chunk) == cap(a.chunk) {
+		a.chunk = make([]T, 0, arenaChunkSize)
+	}
+	a.chunk = append(a.chunk, value)
+	return &a.chunk[len(a.chunk)-1]
+}
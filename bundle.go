@@ -0,0 +1,179 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// bundleMagic ends every bundled executable. A bundle is a copy of the
+// interpreter binary with its contents appended as JSON, followed by their
+// length as a little-endian uint64 and this marker.
+const bundleMagic = "\x00jlox-bundle\x00"
+
+// bundleTrailerSize is the size of the length and marker after the contents.
+const bundleTrailerSize = 8 + len(bundleMagic)
+
+// bundleContents is what a bundle carries: the script and the local
+// scripts it imports, directly or not. Imports are keyed by the path the
+// interpreter resolves them to, relative to the script's directory.
+type bundleContents struct {
+	Name    string            `json:"name"`    // File name of the script
+	Script  []byte            `json:"script"`  // Source of the script
+	Imports map[string][]byte `json:"imports"` // Source of each imported script
+}
+
+// runBundleCommand implements 'jlox bundle script.lox -o app', which writes
+// a self-contained executable that runs the script.
+func (lox *Lox) runBundleCommand(args []string) {
+	bundleFlags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	output := bundleFlags.String("o", "", "Path of the executable to write.")
+	// Accept the script before or after -o.
+	var script string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		script, args = args[0], args[1:]
+	}
+	bundleFlags.Parse(args)
+	if script == "" && bundleFlags.NArg() == 1 {
+		script = bundleFlags.Arg(0)
+	} else if bundleFlags.NArg() != 0 {
+		script = ""
+	}
+	if script == "" || *output == "" {
+		log.Print("Usage: jlox bundle script -o output")
+		os.Exit(EXIT_USAGE)
+	}
+
+	source, err := readScript(script)
+	if err != nil {
+		log.Print(err)
+		lox.exit(EXIT_NOINPUT)
+	}
+	if _, ok := load(string(source), lox); !ok {
+		lox.exit(EXIT_DATAERR)
+	}
+	contents := &bundleContents{Name: filepath.Base(script), Script: source, Imports: map[string][]byte{}}
+	if err := collectImports(source, "", filepath.Dir(script), lox, contents.Imports); err != nil {
+		log.Print("Failed to bundle imports: ", err)
+		lox.exit(EXIT_NOINPUT)
+	}
+	if err := writeBundle(contents, *output); err != nil {
+		log.Print("Failed to write bundle: ", err)
+		lox.exit(EXIT_CANTCREAT)
+	}
+}
+
+// collectImports adds the local scripts that source imports to imports,
+// following their own imports in turn. directory is where the interpreter
+// will resolve source's relative imports, and root is the directory of the
+// bundled script on disk. Standard library and remote imports are left
+// out: the former are built in and the latter are fetched when run.
+func collectImports(source []byte, directory string, root string, lox *Lox, imports map[string][]byte) error {
+	tokens := NewScanner(string(source), lox).ScanTokens()
+	for index, token := range tokens[:len(tokens)-1] {
+		path := tokens[index+1]
+		if token.tokenType != IMPORT || path.tokenType != STRING {
+			continue
+		}
+		location := path.literal.(string)
+		if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") || strings.HasPrefix(location, stdlibPrefix) {
+			continue
+		}
+		location, err := resolveScriptPath(location)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(directory, location)
+		}
+		if _, found := imports[location]; found {
+			continue
+		}
+
+		file := location
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(root, file)
+		}
+		imported, err := readScript(file)
+		if err != nil {
+			return fmt.Errorf("line %v: %w", path.line, err)
+		}
+		imports[location] = imported
+		if err := collectImports(imported, filepath.Dir(location), root, lox, imports); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBundle copies the running interpreter to output with contents appended.
+func writeBundle(contents *bundleContents, output string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	interpreter, err := os.ReadFile(executable)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(contents)
+	if err != nil {
+		return err
+	}
+
+	var bundle bytes.Buffer
+	bundle.Write(interpreter)
+	bundle.Write(payload)
+	binary.Write(&bundle, binary.LittleEndian, uint64(len(payload)))
+	bundle.WriteString(bundleMagic)
+	return os.WriteFile(output, bundle.Bytes(), 0o755)
+}
+
+// readBundle returns the contents attached to the running executable, if any.
+func readBundle() (*bundleContents, bool) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, false
+	}
+	file, err := os.Open(executable)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.Size() < int64(bundleTrailerSize) {
+		return nil, false
+	}
+	trailer := make([]byte, bundleTrailerSize)
+	if _, err := file.ReadAt(trailer, info.Size()-int64(bundleTrailerSize)); err != nil {
+		return nil, false
+	}
+	if string(trailer[8:]) != bundleMagic {
+		return nil, false
+	}
+
+	length := binary.LittleEndian.Uint64(trailer)
+	if length > uint64(info.Size())-uint64(bundleTrailerSize) {
+		return nil, false
+	}
+	payload := make([]byte, length)
+	_, err = file.ReadAt(payload, info.Size()-int64(bundleTrailerSize)-int64(length))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false
+	}
+	var contents bundleContents
+	if json.Unmarshal(payload, &contents) != nil {
+		return nil, false
+	}
+	return &contents, true
+}
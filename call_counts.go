@@ -0,0 +1,29 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// writeCallCounts writes how many times each function was called, most
+// called first, for --count-calls. Functions are identified by name, so
+// methods and functions that share a name are counted together.
+func writeCallCounts(w io.Writer, counts map[string]int) {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if counts[names[a]] != counts[names[b]] {
+			return counts[names[a]] > counts[names[b]]
+		}
+		return names[a] < names[b]
+	})
+
+	fmt.Fprintf(w, "%10v  %v\n", "Calls", "Function")
+	for _, name := range names {
+		fmt.Fprintf(w, "%10v  %v\n", counts[name], name)
+	}
+}
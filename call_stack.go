@@ -0,0 +1,109 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxStackTraceFrames limits how many frames a stack trace prints, so deep
+// recursion doesn't bury the error message.
+const maxStackTraceFrames = 20
+
+// defaultMaxCallDepth is how deeply calls can nest unless WithMaxCallDepth
+// says otherwise. Runaway recursion stops with a runtime error the script
+// can catch, instead of exhausting Go's stack, which would crash the
+// interpreter. It's above the depth simple recursion reached within Go's
+// default 1GB stack, so it only stops scripts that would have crashed; a
+// million nested calls need a larger Go stack, see maxGoStack.
+const defaultMaxCallDepth = 1000000
+
+// maxGoStack is the Go stack size jlox allows, so that calls nested up to
+// defaultMaxCallDepth fit.
+const maxGoStack = min(8<<30, math.MaxInt)
+
+// callFrame records a call in progress, for stack traces.
+type callFrame struct {
+	callee LoxCallable // The function being called
+	line   int         // Line of the call expression
+}
+
+// StackFrame is one entry of a runtime error's stack trace: the function
+// that was running and the line it had reached.
+type StackFrame struct {
+	function string
+	line     int
+}
+
+// pushFrame records a call to callee made on line, or raises a stack
+// overflow error there if calls are already nested too deeply.
+func (i *Interpreter) pushFrame(callee LoxCallable, line int) {
+	if i.maxCallDepth > 0 && len(i.callStack) >= i.maxCallDepth {
+		panic(NewRuntimeError(line, "Stack overflow."))
+	}
+	i.callStack = append(i.callStack, callFrame{callee: callee, line: line})
+}
+
+// stackTrace describes the calls above base that were in progress when a
+// runtime error occurred on line, innermost first.
+func (i *Interpreter) stackTrace(line int, base int) []StackFrame {
+	frames := i.callStack[base:]
+	if len(frames) == 0 {
+		return nil
+	}
+
+	trace := make([]StackFrame, 0, len(frames)+1)
+	for index := len(frames) - 1; index >= 0; index-- {
+		trace = append(trace, StackFrame{function: callableName(frames[index].callee), line: line})
+		line = frames[index].line
+	}
+	return append(trace, StackFrame{function: "script", line: line})
+}
+
+// callableName returns the name a callable is shown with in stack traces.
+func callableName(callee LoxCallable) string {
+	switch c := callee.(type) {
+	case *LoxFunction:
+		return c.declaration.name.lexeme
+	case *LoxClass:
+		return c.name
+	case *NativeFunction:
+		return c.name
+	case *Clock:
+		return "clock"
+	default:
+		return fmt.Sprint(callee)
+	}
+}
+
+// formatStackTrace renders a stack trace with one indented line per frame,
+// e.g. "in fib at line 12" followed by "called from main at line 30".
+// Runs of identical frames, as left by recursion, are printed once.
+func formatStackTrace(trace []StackFrame) string {
+	var builder strings.Builder
+	printed := 0
+	for index := 0; index < len(trace); printed++ {
+		if printed == maxStackTraceFrames {
+			fmt.Fprintf(&builder, "    ... %v more\n", len(trace)-index)
+			break
+		}
+
+		frame := trace[index]
+		repeats := 1
+		for index+repeats < len(trace) && trace[index+repeats] == frame {
+			repeats++
+		}
+		verb := "called from"
+		if index == 0 {
+			verb = "in"
+		}
+		fmt.Fprintf(&builder, "    %v %v at line %v", verb, frame.function, frame.line)
+		if repeats > 1 {
+			fmt.Fprintf(&builder, " (%v times)", repeats)
+		}
+		builder.WriteString("\n")
+		index += repeats
+	}
+	return builder.String()
+}
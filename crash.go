@@ -0,0 +1,116 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// maxCrashDumpDepth limits how deep the AST of the crashing statement is
+// written to a crash report.
+const maxCrashDumpDepth = 12
+
+// recoverCrash turns a panic that isn't a Lox error, i.e. a bug in the
+// interpreter itself, into an apology, the Go stack and a crash report
+// holding the script and the statement that was running. It must be
+// deferred directly.
+func (lox *Lox) recoverCrash(source string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	flushStdout()
+
+	fmt.Fprintln(os.Stderr, "Sorry, jlox crashed. This is a bug in the interpreter, not in your script.")
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s\n", r, stack)
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "jlox crash report\n\n")
+	fmt.Fprintf(&report, "Go runtime: %v (%v/%v)\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&report, "Panic: %v\n\n", r)
+	fmt.Fprintf(&report, "Go stack:\n%s\n", stack)
+	if lox.interpreter != nil && lox.interpreter.statement != nil {
+		if calls := lox.interpreter.callStack; len(calls) > 0 {
+			// Like a native's runtime error, only the innermost call's line is known.
+			fmt.Fprintf(&report, "Lox stack:\n%v\n", formatStackTrace(lox.interpreter.stackTrace(calls[len(calls)-1].line, 0)))
+		}
+		fmt.Fprintf(&report, "Running statement:\n")
+		dumpNode(&report, reflect.ValueOf(lox.interpreter.statement), 1)
+		report.WriteString("\n")
+	} else {
+		fmt.Fprintf(&report, "Crashed before execution. Tokens:\n")
+		dumpTokens(&report, source)
+		report.WriteString("\n")
+	}
+	fmt.Fprintf(&report, "Source:\n%v\n", source)
+
+	file, err := os.CreateTemp("", "jlox-crash-*.txt")
+	if err == nil {
+		_, err = file.WriteString(report.String())
+		file.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write a crash report: %v\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "A crash report was written to %v. Please attach it to a bug report.\n", file.Name())
+	}
+	lox.exit(EXIT_SOFTWARE)
+}
+
+// dumpTokens writes the tokens of source, one per line. Scanning may be
+// what crashed, so a second crash here only ends the listing.
+func dumpTokens(report *strings.Builder, source string) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(report, "  (scanning failed: %v)\n", r)
+		}
+	}()
+	for _, token := range NewScanner(source, nil).ScanTokens() {
+		fmt.Fprintf(report, "  %v:%v %v %q\n", token.line, token.column, token.tokenType.toString(), token.lexeme)
+	}
+}
+
+// dumpNode writes an AST node and its children, indented by depth.
+func dumpNode(report *strings.Builder, value reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if depth > maxCrashDumpDepth {
+		fmt.Fprintf(report, "%v...\n", indent)
+		return
+	}
+	if (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil() {
+		fmt.Fprintf(report, "%vnil\n", indent)
+		return
+	}
+	if value.Type() == reflect.TypeOf(&Token{}) {
+		// AST fields are unexported, so read the token through reflection.
+		token := value.Elem()
+		tokenType := TokenType(token.FieldByName("tokenType").Int())
+		fmt.Fprintf(report, "%v%v %q (line %v)\n", indent, tokenType.toString(), token.FieldByName("lexeme").String(), token.FieldByName("line").Int())
+		return
+	}
+	if value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Slice {
+		for index := 0; index < value.Len(); index++ {
+			dumpNode(report, value.Index(index), depth)
+		}
+		return
+	}
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		fmt.Fprintf(report, "%v%v\n", indent, value)
+		return
+	}
+
+	node := value.Elem()
+	fmt.Fprintf(report, "%v%v\n", indent, node.Type().Name())
+	for field := 0; field < node.NumField(); field++ {
+		fmt.Fprintf(report, "%v  %v:\n", indent, node.Type().Field(field).Name)
+		dumpNode(report, node.Field(field), depth+2)
+	}
+}
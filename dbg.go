@@ -0,0 +1,72 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// defineDbgNative adds dbg(value), which writes value to stderr and returns
+// it, so it can wrap any expression. Calls written as 'dbg(expression)' are
+// parsed into a DbgExpr, which also shows the expression's source and
+// location; the native itself is only called when dbg is used as a value.
+func defineDbgNative(globals *Environment) {
+	globals.define("dbg", NewNativeFunction("dbg", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		if !interpreter.release {
+			interpreter.writeDbg(fmt.Sprintf("[dbg] %v", stringifyValue(arguments[0])))
+		}
+		return arguments[0]
+	}))
+}
+
+// VisitDbgExpr evaluates 'dbg(expression)'. While dbg is the native it
+// writes the location, source text and value of the expression to stderr,
+// then returns the value. Under release it only returns the value. A
+// script that defines its own dbg gets an ordinary call.
+func (i *Interpreter) VisitDbgExpr(expr *DbgExpr) interface{} {
+	native, ok := i.evaluate(expr.call.callee).(*NativeFunction)
+	if !ok || native.name != "dbg" {
+		return i.VisitCallExpr(expr.call)
+	}
+
+	value := i.evaluate(expr.call.arguments[0])
+	if !i.release {
+		name := expr.call.callee.(*VariableExpr).name
+		i.writeDbg(fmt.Sprintf("[line %v, column %v] %v = %v", name.line, name.column, expr.source, stringifyValue(value)))
+	}
+	return value
+}
+
+// writeDbg writes a line of dbg output to stderr, after any buffered
+// prints so the two stay in order.
+func (i *Interpreter) writeDbg(line string) {
+	i.flushOutput()
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	fmt.Fprintln(os.Stderr, line)
+}
+
+// sourceText rebuilds the source of an expression from its tokens, keeping
+// the spacing and line breaks between them. Comments are lost.
+func sourceText(tokens []*Token) string {
+	var text strings.Builder
+	for index, token := range tokens {
+		if index > 0 {
+			previous := tokens[index-1]
+			lines := strings.Count(previous.lexeme, "\n")
+			end := previous.column + utf8.RuneCountInString(previous.lexeme)
+			if lines > 0 {
+				end = utf8.RuneCountInString(previous.lexeme[strings.LastIndex(previous.lexeme, "\n")+1:]) + 1
+			}
+			if token.line > previous.line+lines {
+				text.WriteString("\n" + strings.Repeat(" ", token.column-1))
+			} else if token.column > end {
+				text.WriteString(" ")
+			}
+		}
+		text.WriteString(token.lexeme)
+	}
+	return text.String()
+}
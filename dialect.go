@@ -0,0 +1,71 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Dialect changes how the scanner spells keywords, e.g. to teach Lox
+// in another language. It only affects the scanner's keyword table.
+type Dialect struct {
+	keywords        map[string]TokenType // Keyword table used instead of the default one
+	caseInsensitive bool                 // Match keywords regardless of case
+}
+
+// dialectFile is the JSON layout of a dialect file:
+//
+//	{"caseInsensitive": true, "aliases": {"si": "if", "sinon": "else"}}
+type dialectFile struct {
+	CaseInsensitive bool              `json:"caseInsensitive"`
+	Aliases         map[string]string `json:"aliases"`
+}
+
+// LoadDialect reads a dialect file. Aliases are added alongside the
+// standard keywords, and each must name an existing keyword.
+func LoadDialect(path string) (*Dialect, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file dialectFile
+	if err := json.Unmarshal(bytes, &file); err != nil {
+		return nil, fmt.Errorf("invalid dialect file '%v': %v", path, err)
+	}
+
+	dialect := &Dialect{keywords: map[string]TokenType{}, caseInsensitive: file.CaseInsensitive}
+	for word, tokenType := range keywords {
+		dialect.keywords[word] = tokenType
+	}
+	for alias, keyword := range file.Aliases {
+		tokenType, ok := keywords[keyword]
+		if !ok {
+			return nil, fmt.Errorf("invalid dialect file '%v': '%v' is not a keyword", path, keyword)
+		}
+		if !isIdentifier(alias) {
+			return nil, fmt.Errorf("invalid dialect file '%v': alias '%v' is not a valid identifier", path, alias)
+		}
+		if file.CaseInsensitive {
+			alias = strings.ToLower(alias)
+		}
+		dialect.keywords[alias] = tokenType
+	}
+	return dialect, nil
+}
+
+// isIdentifier reports whether word would be scanned as a single identifier.
+func isIdentifier(word string) bool {
+	if word == "" || (word[0] >= '0' && word[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines a diff shows around each change.
+const diffContext = 3
+
+// diffLine is one line of an edit script: ' ' for a line both sides share,
+// '-' for one only in the expected text and '+' for one only in the actual.
+type diffLine struct {
+	kind     byte
+	text     string
+	expected int // 1-based line in the expected text, for ' ' and '-'
+	actual   int // 1-based line in the actual text, for ' ' and '+'
+}
+
+// diffLines returns the shortest edit script that turns expected into
+// actual, found with a longest common subsequence table.
+func diffLines(expected, actual []string) []diffLine {
+	common := make([][]int, len(expected)+1)
+	for i := range common {
+		common[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			script = append(script, diffLine{' ', expected[i], i + 1, j + 1})
+			i, j = i+1, j+1
+		case j == len(actual) || (i < len(expected) && common[i+1][j] >= common[i][j+1]):
+			script = append(script, diffLine{'-', expected[i], i + 1, j})
+			i++
+		default:
+			script = append(script, diffLine{'+', actual[j], i, j + 1})
+			j++
+		}
+	}
+	return script
+}
+
+// unifiedDiff renders the differences between expected and actual output
+// as a colored unified diff, with removed lines in red and added lines in
+// green. It returns "" if they are the same.
+func unifiedDiff(expected, actual []string) string {
+	script := diffLines(expected, actual)
+	var builder strings.Builder
+	for start := 0; start < len(script); {
+		if script[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// Grow the hunk until the changes are more than two contexts apart.
+		first := max(start-diffContext, 0)
+		end := start
+		for next := start; next < len(script); next++ {
+			if script[next].kind != ' ' {
+				end = next
+			} else if next-end > 2*diffContext {
+				break
+			}
+		}
+		last := min(end+diffContext, len(script)-1)
+
+		hunk := script[first : last+1]
+		expectedStart, expectedCount, actualStart, actualCount := hunkRange(hunk)
+		fmt.Fprintf(&builder, "%v@@ -%v,%v +%v,%v @@%v\n", YELLOW, expectedStart, expectedCount, actualStart, actualCount, RESET)
+		for _, line := range hunk {
+			switch line.kind {
+			case '-':
+				fmt.Fprintf(&builder, "%v-%v%v\n", RED, line.text, RESET)
+			case '+':
+				fmt.Fprintf(&builder, "%v+%v%v\n", GREEN, line.text, RESET)
+			default:
+				fmt.Fprintf(&builder, " %v\n", line.text)
+			}
+		}
+		start = last + 1
+	}
+	return builder.String()
+}
+
+// hunkRange returns the start line and line count of a hunk on each side,
+// in the form a unified diff header uses.
+func hunkRange(hunk []diffLine) (expectedStart, expectedCount, actualStart, actualCount int) {
+	for _, line := range hunk {
+		if line.kind != '+' {
+			if expectedCount == 0 {
+				expectedStart = line.expected
+			}
+			expectedCount++
+		}
+		if line.kind != '-' {
+			if actualCount == 0 {
+				actualStart = line.actual
+			}
+			actualCount++
+		}
+	}
+	if expectedCount == 0 {
+		expectedStart = hunk[0].expected
+	}
+	if actualCount == 0 {
+		actualStart = hunk[0].actual
+	}
+	return expectedStart, expectedCount, actualStart, actualCount
+}
+
+// firstDifference returns the 1-based line where expected and actual first
+// differ, or 0 if they are the same.
+func firstDifference(expected, actual []string) int {
+	for line := 0; line < max(len(expected), len(actual)); line++ {
+		if line >= len(expected) || line >= len(actual) || expected[line] != actual[line] {
+			return line + 1
+		}
+	}
+	return 0
+}
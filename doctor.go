@@ -0,0 +1,113 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// conformanceCase is a small Lox program with its expected output,
+// run by 'jlox doctor' to check the interpreter works on this machine.
+type conformanceCase struct {
+	name     string
+	source   string
+	expected string
+}
+
+var conformanceCases = []conformanceCase{
+	{"arithmetic", "print 1 + 2 * 3; print (1 + 2) * 3;", "7\n9\n"},
+	{"strings", `print "Hello, " + "World!";`, "Hello, World!\n"},
+	{"logic", "print true and !false; print nil or 2;", "true\n2\n"},
+	{"control flow", "var n = 0; for (var i = 0; i < 5; i = i + 1) { if (i == 3) break; n = n + i; } print n;", "3\n"},
+	{"closures", "fun counter() { var c = 0; fun inc() { c = c + 1; return c; } return inc; } var f = counter(); f(); print f();", "2\n"},
+	{"classes", `class A { init(x) { this.x = x; } get() { return this.x; } } class B < A { get() { return super.get() * 2; } } print B(21).get();`, "42\n"},
+	{"loop expression", "var i = 0; print loop { i = i + 1; if (i == 4) break i; };", "4\n"},
+	{"lists", "var l = [1, 2]; push(l, 3); l[0] = 10; print l; print len(l);", "[10, 2, 3]\n3\n"},
+	{"maps", "var m = {\"a\": 1}; m[\"b\"] = 2; remove(m, \"a\"); print keys(m); print m[\"b\"];", "[\"b\"]\n2\n"},
+	{"typed arrays", "var a = Int32Array(2); arraySet(a, 1, 7.5); print arrayGet(a, 1);", "7\n"},
+}
+
+// features lists the optional parts of the interpreter built into this binary.
+var features = []string{
+	"classes (inheritance, this, static methods, getters)",
+	"requires/ensures contracts",
+	"loop expressions",
+	"using resource blocks",
+	"lists",
+	"maps",
+	"typed arrays",
+	"constant folding",
+	"iterative expression evaluator (-iterative)",
+	"JSON event log (--log-json)",
+	"interpreter snapshots",
+}
+
+// runDoctor prints a self-check report for triaging bug reports:
+// the build environment, terminal capabilities, enabled features and
+// the results of a small conformance suite. It returns false if any
+// conformance case failed.
+func (lox *Lox) runDoctor() bool {
+	fmt.Println("Lox doctor")
+	fmt.Println()
+
+	fmt.Println("Environment:")
+	fmt.Printf("  Go runtime:  %v (%v/%v)\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if executable, err := os.Executable(); err == nil {
+		fmt.Printf("  Executable:  %v\n", executable)
+	}
+	fmt.Println("  Backend:     tree-walking interpreter")
+	fmt.Println()
+
+	fmt.Println("Terminal:")
+	fmt.Printf("  stdin is a terminal:   %v\n", isInteractive(os.Stdin))
+	fmt.Printf("  stdout is a terminal:  %v\n", isInteractive(os.Stdout))
+	fmt.Printf("  stderr is a terminal:  %v\n", isInteractive(os.Stderr))
+	fmt.Printf("  TERM:                  %q\n", os.Getenv("TERM"))
+	colors := isInteractive(os.Stderr) && os.Getenv("TERM") != "dumb" && os.Getenv("NO_COLOR") == ""
+	fmt.Printf("  Colored errors:        %v\n", colors)
+	if !colors {
+		fmt.Println("  Note: error messages contain ANSI color codes, which may show up as raw escapes.")
+	}
+	fmt.Println()
+
+	fmt.Println("Features:")
+	for _, feature := range features {
+		fmt.Printf("  - %v\n", feature)
+	}
+	fmt.Println()
+
+	fmt.Println("Conformance:")
+	passed := 0
+	for _, test := range conformanceCases {
+		output := runConformanceCase(test.source)
+		if output == test.expected {
+			passed++
+			fmt.Printf("  ok    %v\n", test.name)
+		} else {
+			fmt.Printf("  FAIL  %v: expected %q, got %q\n", test.name, test.expected, output)
+		}
+	}
+	fmt.Printf("  %v/%v passed\n", passed, len(conformanceCases))
+	return passed == len(conformanceCases)
+}
+
+// runConformanceCase runs source in a fresh interpreter and returns its output.
+func runConformanceCase(source string) string {
+	var output bytes.Buffer
+	interpreter := NewInterpreter(WithOutput(&output))
+
+	statements, ok := load(source, nil)
+	if !ok {
+		return "syntax error"
+	}
+
+	_, err := interpreter.Interpret(statements)
+	interpreter.flushOutput()
+	if err != nil {
+		output.WriteString(plainText(err.Error()))
+	}
+	return strings.ReplaceAll(output.String(), "\r\n", "\n")
+}
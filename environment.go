@@ -3,7 +3,6 @@ package main
 
 import (
 	"fmt"
-	"log"
 )
 
 // Environment represents a scope in the Lox language.
@@ -11,6 +10,9 @@ import (
 type Environment struct {
 	enclosing *Environment // Reference to the enclosing (outer) scope
 	values    map[string]interface{} // Map of variable names to their values
+	constants   map[string]bool // Names declared with const, nil until there is one
+	frozen      bool // Part of a snapshot; bindings can no longer change
+	copyOnWrite bool // Assignments to frozen enclosing bindings are copied here
 }
 
 // NewEnvironment creates a new Environment instance.
@@ -34,35 +36,97 @@ func NewEnclosingEnvironment(enclosing *Environment) *Environment {
 // If the variable already exists, its value is updated.
 func (e *Environment) define(name string, value interface{}) {
 	e.values[name] = value
+	delete(e.constants, name)
+}
+
+// defineConstant defines a variable in the current scope that can't be
+// assigned to afterwards. Redeclaring the name replaces the binding.
+func (e *Environment) defineConstant(name string, value interface{}) {
+	e.values[name] = value
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
 }
 
 // get retrieves the value of a variable.
 // Searches in the current scope and then in enclosing scopes.
 func (e *Environment) get(name *Token) interface{} {
-	if value, ok := e.values[name.lexeme]; ok {
-		return value
+	return e.getIn(name, nil)
+}
+
+// getIn is get for code run by an interpreter whose global layer is
+// globals. See next for how that changes the search.
+func (e *Environment) getIn(name *Token, globals *Environment) interface{} {
+	for env := e; env != nil; env = env.next(globals) {
+		if value, ok := env.values[name.lexeme]; ok {
+			return value
+		}
 	}
 
-	if e.enclosing != nil {
-		return e.enclosing.get(name)
+	panic(NewRuntimeErrorAt(name, fmt.Sprintf("Undefined variable %v'%v'%v.", YELLOW, name.lexeme, RESET)))
+}
+
+// next returns the scope a search continues in after e. That's normally
+// the enclosing scope, but code declared before a snapshot closes over the
+// frozen globals; when a fork runs it, the search moves to the fork's
+// copy-on-write layer, globals, so it sees and assigns the fork's own
+// bindings.
+func (e *Environment) next(globals *Environment) *Environment {
+	if globals != nil && e != globals && !e.frozen && e.enclosing != nil && e.enclosing.frozen {
+		return globals
 	}
+	return e.enclosing
+}
 
-	log.Fatal(ReportExit(name.line, "", fmt.Sprintf("Undefined variable %v'%v'%v.", YELLOW, name.lexeme, RESET)))
-	return nil
+// has reports whether a variable is defined in this scope or an enclosing one.
+func (e *Environment) has(name string) bool {
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.values[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isConstant reports whether the closest binding of name was declared
+// with const.
+func (e *Environment) isConstant(name string) bool {
+	for env := e; env != nil; env = env.enclosing {
+		if _, ok := env.values[name]; ok {
+			return env.constants[name]
+		}
+	}
+	return false
 }
 
 // assign updates the value of an existing variable.
 // Searches in the current scope and then in enclosing scopes.
 func (e *Environment) assign(name *Token, value interface{}) {
-	if _, ok := e.values[name.lexeme]; ok {
-		e.values[name.lexeme] = value
-		return
-	}
+	e.assignIn(name, value, nil)
+}
+
+// assignIn is assign for code run by an interpreter whose global layer is
+// globals, searching scopes like getIn.
+func (e *Environment) assignIn(name *Token, value interface{}, globals *Environment) {
+	for env := e; env != nil; env = env.next(globals) {
+		if env.constants[name.lexeme] {
+			panic(NewRuntimeErrorAt(name, fmt.Sprintf("Can't assign to constant %v'%v'%v.", YELLOW, name.lexeme, RESET)))
+		}
+
+		if _, ok := env.values[name.lexeme]; ok {
+			if env.frozen {
+				panic(NewRuntimeErrorAt(name, fmt.Sprintf("Can't assign to snapshot global %v'%v'%v from snapshot code.", YELLOW, name.lexeme, RESET)))
+			}
+			env.values[name.lexeme] = value
+			return
+		}
 
-	if e.enclosing != nil {
-		e.enclosing.assign(name, value)
-		return
+		if env.copyOnWrite && env.enclosing.has(name.lexeme) && !env.enclosing.isConstant(name.lexeme) {
+			env.values[name.lexeme] = value
+			return
+		}
 	}
 
-	log.Fatal(ReportExit(name.line, "", fmt.Sprintf("Undefined variable %v'%v'%v.", YELLOW, name.lexeme, RESET)))
+	panic(NewRuntimeErrorAt(name, fmt.Sprintf("Undefined variable %v'%v'%v.", YELLOW, name.lexeme, RESET)))
 }
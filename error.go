@@ -3,12 +3,14 @@ package main
 
 import (
 	"fmt"
+	"log"
 )
 
 // Terminal colors for error reporting
 const (
 	RED    = "\033[31m"
 	YELLOW = "\033[33m"
+	GREEN  = "\033[32m"
 	RESET  = "\033[0m"
 	LINE_UNKNOWN = -1
 )
@@ -16,22 +18,74 @@ const (
 // Report generates an error message with line number and location information.
 // Used for reporting syntax and runtime errors.
 // Parameters:
-//   - line: The line number where the error occurred
+//   - line: The line number where the error occurred, or LINE_UNKNOWN
+//   - column: The column where the error occurred, or 0 if unknown
 //   - where: Additional location information (e.g., token or expression)
 //   - message: The error message describing the problem
-func Report(line int, where string, message string) string {
+func Report(line int, column int, where string, message string) string {
+	if line == LINE_UNKNOWN {
+		return fmt.Sprintf("%vError%v: %v\n", RED, RESET, message)
+	}
+	position := fmt.Sprintf("line %v", line)
+	if column > 0 {
+		position = fmt.Sprintf("line %v, column %v", line, column)
+	}
 	if where == "" {
-		return fmt.Sprintf("%v[line %v]%v Error: %v\n", RED, line, RESET, message)
+		return fmt.Sprintf("%v[%v]%v Error: %v\n", RED, position, RESET, message)
 	}
-	return fmt.Sprintf("%v[line %v]%v Error %v: %v\n", RED, line, RESET, where, message)
+	return fmt.Sprintf("%v[%v]%v Error %v: %v\n", RED, position, RESET, where, message)
+}
+
+// ReportWarning generates a warning message with line number information.
+// Used for problems that don't stop the program.
+func ReportWarning(line int, message string) string {
+	return fmt.Sprintf("%v[line %v]%v Warning: %v\n", YELLOW, line, RESET, message)
 }
 
-// ReportExit generates an error message and formats it for display before exit.
-// Used for fatal errors that should terminate the program.
+// ReportError logs an error message and records it in the event log.
+// Used for errors that are reported without stopping the process.
 // Parameters:
-//   - line: The line number where the error occurred
+//   - line: The line number where the error occurred, or LINE_UNKNOWN
+//   - column: The column where the error occurred, or 0 if unknown
 //   - where: Additional location information
 //   - message: The error message
-func ReportExit(line int, where string, message string) string {
-	return Report(line, where, message)
+func ReportError(line int, column int, where string, message string) {
+	events.emit("error", map[string]interface{}{"line": line, "column": column, "where": where, "message": plainText(message)})
+	log.Print(Report(line, column, where, message))
+}
+
+// RuntimeError is an error raised while executing a Lox program.
+// It is thrown with panic and unwinds back to Interpreter.Interpret,
+// which returns it so the caller can decide whether to keep going.
+type RuntimeError struct {
+	line    int    // The line the error occurred on, or LINE_UNKNOWN
+	column  int    // The column the error occurred at, or 0 if unknown
+	message string // The error message describing the problem
+	stack   []StackFrame // Calls in progress when the error occurred, innermost first
+	cause   error        // Why the script was interrupted, if it was
+	thrown  interface{}  // Value of the throw statement that raised it, nil for other errors
+
+	environment *Environment // Innermost scope when the error was raised, kept for post-mortem inspection
+}
+
+// NewRuntimeError creates a RuntimeError for the given line.
+func NewRuntimeError(line int, message string) *RuntimeError {
+	return &RuntimeError{line: line, message: message}
+}
+
+// NewRuntimeErrorAt creates a RuntimeError at the position of token.
+func NewRuntimeErrorAt(token *Token, message string) *RuntimeError {
+	return &RuntimeError{line: token.line, column: token.column, message: message}
+}
+
+// Error formats the runtime error like any other reported error, followed
+// by its stack trace when it happened inside a function.
+func (e *RuntimeError) Error() string {
+	return Report(e.line, e.column, "", e.message) + formatStackTrace(e.stack)
+}
+
+// Unwrap returns the cause of an interrupted script, so callers can test
+// for it with errors.Is, e.g. errors.Is(err, context.Canceled).
+func (e *RuntimeError) Unwrap() error {
+	return e.cause
 }
@@ -0,0 +1,53 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// events is the structured event log enabled with --log-json.
+// It is nil when event logging is disabled.
+var events *EventLog
+
+// EventLog writes machine-readable runtime events as JSON lines, for test
+// harnesses and CI systems that wrap the interpreter.
+// Events from interpreters running concurrently are written whole.
+type EventLog struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewEventLog creates a new EventLog writing to writer.
+func NewEventLog(writer io.Writer) *EventLog {
+	return &EventLog{encoder: json.NewEncoder(writer)}
+}
+
+// emit writes a single event. Calling emit on a nil EventLog does nothing.
+func (l *EventLog) emit(event string, fields map[string]interface{}) {
+	if l == nil {
+		return
+	}
+
+	record := map[string]interface{}{
+		"event": event,
+		"time":  time.Now().Format(time.RFC3339Nano),
+	}
+	for key, value := range fields {
+		record[key] = value
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.encoder.Encode(record)
+}
+
+// colorCodes strips the terminal colors used in error messages.
+var colorCodes = strings.NewReplacer(RED, "", YELLOW, "", GREEN, "", RESET, "")
+
+// plainText removes terminal colors from a message.
+func plainText(message string) string {
+	return colorCodes.Replace(message)
+}
@@ -4,9 +4,24 @@ type ExprVisitor interface {
 	VisitAssignExpr(*AssignExpr) interface{}
 	VisitBinaryExpr(*BinaryExpr) interface{}
 	VisitCallExpr(*CallExpr) interface{}
+	VisitConditionalExpr(*ConditionalExpr) interface{}
+	VisitIndexExpr(*IndexExpr) interface{}
+	VisitIndexSetExpr(*IndexSetExpr) interface{}
+	VisitSliceExpr(*SliceExpr) interface{}
+	VisitListExpr(*ListExpr) interface{}
+	VisitDbgExpr(*DbgExpr) interface{}
+	VisitFunctionExpr(*FunctionExpr) interface{}
+	VisitMapExpr(*MapExpr) interface{}
+	VisitGetExpr(*GetExpr) interface{}
+	VisitOptionalChainExpr(*OptionalChainExpr) interface{}
+	VisitMatchExpr(*MatchExpr) interface{}
 	VisitGroupingExpr(*GroupingExpr) interface{}
 	VisitLiteralExpr(*LiteralExpr) interface{}
 	VisitLogicalExpr(*LogicalExpr) interface{}
+	VisitLoopExpr(*LoopExpr) interface{}
+	VisitSetExpr(*SetExpr) interface{}
+	VisitSuperExpr(*SuperExpr) interface{}
+	VisitThisExpr(*ThisExpr) interface{}
 	VisitUnaryExpr(*UnaryExpr) interface{}
 	VisitVariableExpr(*VariableExpr) interface{}
 }
@@ -32,6 +47,68 @@ type CallExpr struct {
 	arguments []Expr
 }
 
+type ConditionalExpr struct {
+	condition Expr
+	thenBranch Expr
+	elseBranch Expr
+}
+
+type IndexExpr struct {
+	object Expr
+	bracket *Token
+	index Expr
+}
+
+type IndexSetExpr struct {
+	object Expr
+	bracket *Token
+	index Expr
+	value Expr
+}
+
+type SliceExpr struct {
+	object Expr
+	bracket *Token
+	start Expr
+	end Expr
+}
+
+type ListExpr struct {
+	bracket *Token
+	elements []Expr
+}
+
+type DbgExpr struct {
+	call *CallExpr
+	source string
+}
+
+type FunctionExpr struct {
+	declaration *FunctionStmt
+}
+
+type MapExpr struct {
+	brace *Token
+	keys []Expr
+	values []Expr
+}
+
+type GetExpr struct {
+	object Expr
+	name *Token
+	optional bool
+}
+
+type OptionalChainExpr struct {
+	expression Expr
+}
+
+type MatchExpr struct {
+	keyword *Token
+	subject Expr
+	arms []*MatchArm
+}
+
 type GroupingExpr struct {
 	expression Expr
 }
@@ -46,6 +123,27 @@ type LogicalExpr struct {
 	right Expr
 }
 
+type LoopExpr struct {
+	keyword *Token
+	body Stmt
+	label *Token
+}
+
+type SetExpr struct {
+	object Expr
+	name *Token
+	value Expr
+}
+
+type SuperExpr struct {
+	keyword *Token
+	method *Token
+}
+
+type ThisExpr struct {
+	keyword *Token
+}
+
 type UnaryExpr struct {
 	operator *Token
 	right Expr
@@ -67,6 +165,50 @@ func (c *CallExpr) accept(visitor ExprVisitor) interface{} {
 	return visitor.VisitCallExpr(c)
 }
 
+func (c *ConditionalExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitConditionalExpr(c)
+}
+
+func (i *IndexExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitIndexExpr(i)
+}
+
+func (i *IndexSetExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitIndexSetExpr(i)
+}
+
+func (s *SliceExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitSliceExpr(s)
+}
+
+func (l *ListExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitListExpr(l)
+}
+
+func (d *DbgExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitDbgExpr(d)
+}
+
+func (f *FunctionExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitFunctionExpr(f)
+}
+
+func (m *MapExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitMapExpr(m)
+}
+
+func (g *GetExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitGetExpr(g)
+}
+
+func (o *OptionalChainExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitOptionalChainExpr(o)
+}
+
+func (m *MatchExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitMatchExpr(m)
+}
+
 func (g *GroupingExpr) accept(visitor ExprVisitor) interface{} {
 	return visitor.VisitGroupingExpr(g)
 }
@@ -79,6 +221,22 @@ func (l *LogicalExpr) accept(visitor ExprVisitor) interface{} {
 	return visitor.VisitLogicalExpr(l)
 }
 
+func (l *LoopExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitLoopExpr(l)
+}
+
+func (s *SetExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitSetExpr(s)
+}
+
+func (s *SuperExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitSuperExpr(s)
+}
+
+func (t *ThisExpr) accept(visitor ExprVisitor) interface{} {
+	return visitor.VisitThisExpr(t)
+}
+
 func (u *UnaryExpr) accept(visitor ExprVisitor) interface{} {
 	return visitor.VisitUnaryExpr(u)
 }
@@ -0,0 +1,125 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// iterator produces the values a for-in loop visits, one per call, until
+// ok is false.
+type iterator func() (value interface{}, ok bool)
+
+// iterate returns an iterator over a list's elements, a map's keys, a
+// string's characters or an enum's members. A list is read as the loop goes, so elements pushed
+// by the body are visited; a map's keys are the ones it had when the loop
+// started.
+//
+// Instances take part through the iterator protocol. An iterable object
+// has an 'iterate()' method returning an iterator; an object without one
+// is taken to be an iterator itself. An iterator has a 'done' property,
+// which can be a field, a getter or a method, and a 'next()' method
+// returning the next value. The loop reads 'done' before every call to
+// 'next()' and stops once it's truthy.
+func (i *Interpreter) iterate(keyword *Token, iterable interface{}) iterator {
+	index := 0
+	switch iterable := iterable.(type) {
+	case *LoxList:
+		return func() (interface{}, bool) {
+			if index >= len(iterable.elements) {
+				return nil, false
+			}
+			index++
+			return iterable.elements[index-1], true
+		}
+	case *LoxMap:
+		keys := slices.Clone(iterable.keys)
+		return func() (interface{}, bool) {
+			if index >= len(keys) {
+				return nil, false
+			}
+			index++
+			return keys[index-1], true
+		}
+	case *LoxEnum:
+		return func() (interface{}, bool) {
+			if index >= len(iterable.members) {
+				return nil, false
+			}
+			index++
+			return iterable.members[index-1], true
+		}
+	case string:
+		characters := []rune(iterable)
+		return func() (interface{}, bool) {
+			if index >= len(characters) {
+				return nil, false
+			}
+			index++
+			return string(characters[index-1]), true
+		}
+	case *LoxInstance:
+		source := iterable
+		if iterable.class.findMethod("iterate") != nil {
+			var ok bool
+			if source, ok = i.callProtocolMethod(keyword, iterable, "iterate").(*LoxInstance); !ok {
+				panic(NewRuntimeErrorAt(keyword, fmt.Sprintf("%v'iterate()'%v must return an iterator object.", YELLOW, RESET)))
+			}
+		}
+		return func() (interface{}, bool) {
+			if i.isTruthy(i.callProtocolMethod(keyword, source, "done")) {
+				return nil, false
+			}
+			return i.callProtocolMethod(keyword, source, "next"), true
+		}
+	}
+	panic(NewRuntimeErrorAt(keyword, "Can only loop over lists, maps, strings, enums and iterable objects."))
+}
+
+// callProtocolMethod reads property name of instance for the iterator
+// protocol, calling it if it's a method. Errors are reported at the loop's
+// keyword, and the call shows up in stack traces like any other.
+func (i *Interpreter) callProtocolMethod(keyword *Token, instance *LoxInstance, name string) interface{} {
+	token := &Token{tokenType: IDENTIFIER, lexeme: name, line: keyword.line, column: keyword.column}
+	value := i.callGetter(instance.get(token), token)
+	function, ok := value.(LoxCallable)
+	if !ok {
+		return value
+	}
+	if function.arity() != 0 {
+		panic(NewRuntimeErrorAt(keyword, fmt.Sprintf("Iterator method %v'%v'%v must not take parameters.", YELLOW, name, RESET)))
+	}
+	return i.callWithFrame(function, nil, keyword.line)
+}
+
+// VisitForInStmt runs 'for (var name in iterable) body'. Each iteration
+// gets its own environment, so closures created in the body capture that
+// iteration's value.
+func (i *Interpreter) VisitForInStmt(stmt *ForInStmt) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			if breakError, ok := r.(*BreakError); !ok || !breakError.targets(stmt.label) {
+				panic(r) // re-panic if it's not a break of this loop
+			}
+		}
+	}()
+
+	next := i.iterate(stmt.keyword, i.evaluate(stmt.iterable))
+	var result interface{}
+	for value, ok := next(); ok; value, ok = next() {
+		i.checkInterrupt(stmt.keyword)
+		environment := NewEnclosingEnvironment(i.environment)
+		environment.define(stmt.name.lexeme, value)
+		result = i.executeBlock([]Stmt{stmt.body}, environment)
+		switch signal := result.(type) {
+		case *ReturnError:
+			return result
+		case *ContinueError:
+			if !signal.targets(stmt.label) {
+				return result // continue an outer loop
+			}
+			result = nil
+		}
+	}
+	return result
+}
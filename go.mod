@@ -1,3 +1,5 @@
 module github.com/Coughyyee/Go-lox-interpreter
 
 go 1.22.1
+
+require golang.org/x/text v0.21.0
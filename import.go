@@ -0,0 +1,175 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VisitImportStmt runs another script in the global environment, so the
+// functions and classes it declares become visible to the importer.
+// Each file or URL is only run once per interpreter; importing it again
+// (including through a cycle) does nothing.
+//
+// Paths starting with 'std/' name standard library modules, which are
+// built into the interpreter. Other local paths are relative to the
+// importing script. HTTPS URLs are downloaded once and kept in an on-disk
+// cache; a '#sha256=<hex>' fragment pins the expected content, which is
+// then verified on every download.
+// Remote imports are disabled in sandbox mode.
+func (i *Interpreter) VisitImportStmt(stmt *ImportStmt) interface{} {
+	location := stmt.path.literal.(string)
+	var source []byte
+	var err error
+	directory := i.scriptDir
+	if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") {
+		if i.sandbox {
+			panic(NewRuntimeErrorAt(stmt.path, "Remote imports are disabled in sandbox mode."))
+		}
+		if !strings.HasPrefix(location, "https://") {
+			panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("Remote import %v'%v'%v must use HTTPS.", YELLOW, location, RESET)))
+		}
+		if i.imported[location] {
+			return nil
+		}
+		source, err = fetchRemoteImport(location)
+	} else if strings.HasPrefix(location, stdlibPrefix) {
+		location = stdlibModule(location)
+		if i.imported[location] {
+			return nil
+		}
+		source, err = stdlib.ReadFile(location)
+	} else {
+		location, err = resolveScriptPath(location)
+		if err == nil && !filepath.IsAbs(location) {
+			location = filepath.Join(i.scriptDir, location)
+		}
+		if i.imported[location] {
+			return nil
+		}
+		if bundled, found := i.bundled[location]; found {
+			source = bundled
+		} else if err == nil {
+			source, err = readScript(location)
+		}
+		directory = filepath.Dir(location)
+	}
+	if err != nil {
+		panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("Failed to import %v'%v'%v: %v", YELLOW, stmt.path.literal, RESET, err)))
+	}
+
+	// Imports see the importer's defines and keywords. The standard library
+	// is written in standard Lox whatever the dialect.
+	settings := &Lox{defines: i.constants, dialect: i.dialect, interpreter: i}
+	if strings.HasPrefix(location, stdlibPrefix) {
+		settings.dialect = nil
+	}
+	statements, ok := load(string(source), settings)
+	if !ok {
+		panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("Imported script %v'%v'%v has syntax errors.", YELLOW, stmt.path.literal, RESET)))
+	}
+
+	previousDirectory := i.scriptDir
+	i.enterScript(location)
+	i.scriptDir = directory
+	defer func() { i.scriptDir = previousDirectory }()
+	i.executeBlock(statements, i.globals)
+	return nil
+}
+
+// enterScript records that the script at path is running, so imports
+// resolve relative to it and importing it again does nothing.
+func (i *Interpreter) enterScript(path string) {
+	if i.imported == nil {
+		i.imported = map[string]bool{}
+	}
+	i.imported[path] = true
+	i.scriptDir = filepath.Dir(path)
+}
+
+// importClient downloads remote imports.
+var importClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchRemoteImport returns the script at an HTTPS URL, from the cache if
+// possible. Objects in the cache are named by their content hash, and an
+// index file per URL remembers which object it resolved to.
+func fetchRemoteImport(location string) ([]byte, error) {
+	address, fragment, _ := strings.Cut(location, "#")
+	pinned := ""
+	if fragment != "" {
+		var found bool
+		pinned, found = strings.CutPrefix(fragment, "sha256=")
+		if !found {
+			return nil, fmt.Errorf("unsupported fragment '#%v', expected '#sha256=<hex>'", fragment)
+		}
+		pinned = strings.ToLower(pinned)
+	}
+
+	cache := importCacheDir()
+	index := ""
+	if cache != "" {
+		index = filepath.Join(cache, hashHex([]byte(address))+".url")
+		hash := pinned
+		if hash == "" {
+			if indexed, err := os.ReadFile(index); err == nil {
+				hash = strings.TrimSpace(string(indexed))
+			}
+		}
+		if hash != "" {
+			if source, err := os.ReadFile(filepath.Join(cache, hash+".lox")); err == nil && hashHex(source) == hash {
+				return source, nil
+			}
+		}
+	}
+
+	response, err := importClient.Get(address)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %v", response.Status)
+	}
+	source, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashHex(source)
+	if pinned != "" && hash != pinned {
+		return nil, fmt.Errorf("content hash mismatch: expected sha256 %v but downloaded %v", pinned, hash)
+	}
+	// The cache is only an optimization; failing to write it isn't an error.
+	if cache != "" && os.MkdirAll(cache, 0o755) == nil {
+		if os.WriteFile(filepath.Join(cache, hash+".lox"), source, 0o644) == nil {
+			os.WriteFile(index, []byte(hash+"\n"), 0o644)
+		}
+	}
+	return source, nil
+}
+
+// importCacheDir returns the directory remote imports are cached in, or ""
+// if there's no usable cache directory.
+func importCacheDir() string {
+	if dir := os.Getenv("JLOX_CACHE"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "jlox", "imports")
+}
+
+// hashHex returns the hex-encoded SHA-256 of data.
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxExactInteger is the magnitude below which every whole number is
+// exactly representable as a Lox number (a float64). It is itself exact,
+// but so is 2^53 + 1 rounded down to it, so results of this size already
+// may have lost precision.
+const maxExactInteger = 1 << 53
+
+// isWholeNumber reports whether value has no fractional part.
+func isWholeNumber(value float64) bool {
+	return value == math.Trunc(value) && !math.IsInf(value, 0)
+}
+
+// integerOperand converts an operand of a bitwise operator to an integer.
+// Normally it truncates towards zero; with checked integers an operand
+// that isn't a whole number, or doesn't fit in 64 bits, is an error
+// instead of being silently changed.
+func (i *Interpreter) integerOperand(operator *Token, value float64) int64 {
+	if i.checkedIntegers {
+		if !isWholeNumber(value) {
+			panic(NewRuntimeErrorAt(operator, fmt.Sprintf("Operand of %v'%v'%v must be a whole number, not %v.", YELLOW, operator.lexeme, RESET, value)))
+		}
+		if value < math.MinInt64 || value >= math.MaxInt64 {
+			panic(NewRuntimeErrorAt(operator, fmt.Sprintf("Operand of %v'%v'%v is too large for an integer: %v.", YELLOW, operator.lexeme, RESET, value)))
+		}
+	}
+	return toInteger(value)
+}
+
+// integerResult converts the result of a bitwise operator back to a
+// number. With checked integers a result too large to be exact is an
+// overflow error rather than being rounded.
+func (i *Interpreter) integerResult(operator *Token, result int64) float64 {
+	if i.checkedIntegers && (result >= maxExactInteger || result <= -maxExactInteger) {
+		panic(NewRuntimeErrorAt(operator, fmt.Sprintf("Integer overflow in %v'%v'%v: the result is too large to be exact.", YELLOW, operator.lexeme, RESET)))
+	}
+	return float64(result)
+}
+
+// checkArithmeticOverflow stops with an overflow error, under checked
+// integers, when arithmetic on two whole numbers gives a result too large
+// to be exact. It returns result otherwise.
+func (i *Interpreter) checkArithmeticOverflow(operator *Token, left, right, result float64) float64 {
+	if i.checkedIntegers && isWholeNumber(left) && isWholeNumber(right) && math.Abs(result) >= maxExactInteger {
+		panic(NewRuntimeErrorAt(operator, fmt.Sprintf("Integer overflow in %v'%v'%v: the result is too large to be exact.", YELLOW, operator.lexeme, RESET)))
+	}
+	return result
+}
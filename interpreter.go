@@ -2,29 +2,149 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"log"
+	"math"
+	"slices"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // Interpreter implements the execution engine for the Lox language.
 // It evaluates expressions and executes statements in the AST.
+//
+// An Interpreter must only be used by one goroutine at a time, but separate
+// interpreters, including forks of one snapshot, can run concurrently in
+// one process. Output they share, such as stdout, is written a whole print
+// at a time; values they share, such as instances in a snapshot, aren't
+// locked.
 type Interpreter struct {
+	natives     *Environment // Built-in functions, enclosing the globals
 	globals     *Environment
 	environment *Environment
+	release     bool          // Skip contract checks on function calls
+	out         *bufio.Writer // Destination of print statements
+	flushPrints bool          // Flush out after every print instead of buffering
+	iterative   bool          // Evaluate operator trees with an explicit stack
+
+	forbidNativeShadowing bool // Make redefining a native global an error instead of a warning
+	sandbox               bool // Disallow remote imports and native plugins
+	optionalArguments     bool // Pad missing arguments with nil and drop extra ones
+	checkedIntegers       bool // Make integer overflow and lossy truncation errors
+	postMortem            bool // Keep the scope of runtime errors for inspection
+
+	constants Defines           // Global constants defined by WithConstants
+	dialect   *Dialect          // Keyword spellings of imported scripts, nil for standard Lox
+	bundled   map[string][]byte // Scripts carried by a bundle, by import path
+	scriptDir string            // Directory of the running script, for relative imports
+	imported  map[string]bool   // Scripts already run by import
+
+	argumentStack []interface{}                    // Reused storage for call arguments
+	callStack     []callFrame                      // Calls in progress, for stack traces
+	statement     Stmt                             // Top-level statement being run, for crash reports
+	interruption  atomic.Pointer[interruptRequest] // Error to stop the script with, set by interrupt
+	stats         runStats                         // Counters reported by stats()
+	callCounts    map[string]int                   // Calls per function name, nil unless counting
+	timeLimit     time.Duration                    // Stop each Interpret after this long, 0 for no limit
+	maxCallDepth  int                              // Calls that can nest before a stack overflow error, 0 for no limit
+	timed         bool                             // Whether the running Interpret is timed
 }
 
-// NewInterpreter creates a new Interpreter instance.
-func NewInterpreter() *Interpreter {
-	globals := NewEnvironment()
-	globals.define("clock", NewClock())
-	return &Interpreter{
+// NewInterpreter creates a new Interpreter instance configured by opts.
+// Without options it prints to stdout, flushing after every print.
+// Natives live in their own environment enclosing the globals, so user
+// definitions never overwrite them and resetting the globals keeps them.
+func NewInterpreter(opts ...Option) *Interpreter {
+	natives := NewEnvironment()
+	natives.define("clock", NewClock())
+	natives.define("globals", NewNativeFunction("globals", 0, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		// Inner scopes shadow outer ones, from the globals out to the natives.
+		bindings := make(map[string]interface{})
+		for env := interpreter.globals; env != nil; env = env.enclosing {
+			for name, value := range env.values {
+				if _, ok := bindings[name]; !ok {
+					bindings[name] = value
+				}
+			}
+		}
+
+		var names []string
+		for name := range bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		values := NewLoxMap()
+		for _, name := range names {
+			values.set(name, bindings[name])
+		}
+		return values
+	}))
+	defineTypedArrayNatives(natives)
+	defineFileNatives(natives)
+	defineStringNatives(natives)
+	defineListNatives(natives)
+	defineMapNatives(natives)
+	defineLocaleNatives(natives)
+	defineStatsNatives(natives)
+	defineDbgNative(natives)
+	defineRetryNatives(natives)
+	defineAssertNative(natives)
+	defineErrorClass(natives)
+
+	globals := NewEnclosingEnvironment(natives)
+	interpreter := &Interpreter{
+		natives:     natives,
 		globals:     globals,
 		environment: globals,
+		out:         stdout,
+		flushPrints: true,
+
+		maxCallDepth: defaultMaxCallDepth,
+	}
+	for _, opt := range opts {
+		opt(interpreter)
+	}
+	return interpreter
+}
+
+// ResetGlobals discards every global defined by scripts.
+// Natives are kept.
+func (i *Interpreter) ResetGlobals() {
+	i.globals = NewEnclosingEnvironment(i.natives)
+	i.environment = i.globals
+	i.defineConstants()
+}
+
+// defineConstants defines the constants given with WithConstants as
+// globals.
+func (i *Interpreter) defineConstants() {
+	for name, value := range i.constants {
+		i.globals.defineConstant(name, value)
 	}
 }
 
+// checkNativeShadowing warns when a global definition hides a native,
+// or stops with an error when native shadowing is forbidden.
+func (i *Interpreter) checkNativeShadowing(name *Token) {
+	if i.environment != i.globals {
+		return
+	}
+	if _, ok := i.natives.values[name.lexeme]; !ok {
+		return
+	}
+
+	message := fmt.Sprintf("%v'%v'%v shadows a native function.", YELLOW, name.lexeme, RESET)
+	if i.forbidNativeShadowing {
+		panic(NewRuntimeErrorAt(name, message))
+	}
+	log.Print(ReportWarning(name.line, message))
+}
+
 type Clock struct{}
 
 func NewClock() *Clock {
@@ -45,12 +165,95 @@ func (*Clock) String() string {
 
 // Interpret interprets a list of statements.
 // This is the main entry point for program execution.
-func (i *Interpreter) Interpret(statements []Stmt) interface{} {
-	var result interface{}
+// A runtime error stops execution and is returned; the interpreter is left
+// ready to run more statements, with any globals defined so far kept.
+func (i *Interpreter) Interpret(statements []Stmt) (result interface{}, err error) {
+	if i.timeLimit > 0 && !i.timed {
+		// Only the outermost run is timed, e.g. not a RunFile from a native.
+		i.timed = true
+		cancel := i.limitTime(i.timeLimit)
+		defer func() {
+			cancel()
+			i.timed = false
+		}()
+	}
+	environment := i.environment
+	argumentBase := len(i.argumentStack)
+	callBase := len(i.callStack)
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeError, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r) // re-panic if it's not a runtime error
+			}
+			if runtimeError.line == LINE_UNKNOWN && len(i.callStack) > callBase {
+				// Natives don't know where they were called from.
+				runtimeError.line = i.callStack[len(i.callStack)-1].line
+			}
+			if runtimeError.stack == nil {
+				// Otherwise it was recorded where the error was first caught.
+				runtimeError.stack = i.stackTrace(runtimeError.line, callBase)
+			}
+			i.environment = environment
+			clear(i.argumentStack[argumentBase:])
+			i.argumentStack = i.argumentStack[:argumentBase]
+			clear(i.callStack[callBase:])
+			i.callStack = i.callStack[:callBase]
+			i.statement = nil
+			result, err = nil, runtimeError
+		}
+	}()
+
 	for _, statement := range statements {
+		i.statement = statement
 		result = i.execute(statement)
 	}
-	return result
+	i.statement = nil
+	return result, nil
+}
+
+// InterpretContext is Interpret, stopping the script with an "Execution
+// cancelled." runtime error when ctx is cancelled or its deadline passes.
+// The script notices at its next loop iteration or call, including calls
+// made from natives and files run by them. The returned error wraps the
+// context's cause.
+func (i *Interpreter) InterpretContext(ctx context.Context, statements []Stmt) (interface{}, error) {
+	if ctx.Err() != nil {
+		err := NewRuntimeError(LINE_UNKNOWN, cancellationMessage(ctx))
+		err.cause = context.Cause(ctx)
+		return nil, err
+	}
+	stop := i.watchContext(ctx)
+	defer stop()
+	return i.Interpret(statements)
+}
+
+// RunFile scans, parses and runs the script at path in this interpreter.
+// Globals defined by the script stay visible to later runs, which makes it
+// suitable for preloading library files before a main script.
+func (i *Interpreter) RunFile(path string) error {
+	bytes, err := readScript(path)
+	if err != nil {
+		return err
+	}
+
+	statements, ok := load(string(bytes), nil)
+	if !ok {
+		return fmt.Errorf("syntax errors in '%v'", path)
+	}
+
+	_, err = i.Interpret(statements)
+	if flushErr := i.flushOutput(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// flushOutput writes any buffered output of print statements.
+func (i *Interpreter) flushOutput() error {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	return i.out.Flush()
 }
 
 // VisitLiteralExpr evaluates a literal expression.
@@ -59,6 +262,15 @@ func (i *Interpreter) VisitLiteralExpr(expr *LiteralExpr) interface{} {
 	return expr.value
 }
 
+// VisitConditionalExpr evaluates a 'condition ? then : else' expression.
+// Only the chosen branch is evaluated.
+func (i *Interpreter) VisitConditionalExpr(expr *ConditionalExpr) interface{} {
+	if i.isTruthy(i.evaluate(expr.condition)) {
+		return i.evaluate(expr.thenBranch)
+	}
+	return i.evaluate(expr.elseBranch)
+}
+
 func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) interface{} {
 	left := i.evaluate(expr.left)
 
@@ -75,6 +287,32 @@ func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) interface{} {
 	return i.evaluate(expr.right)
 }
 
+// VisitLoopExpr evaluates a loop expression.
+// Runs the body until a break, yielding the break's value (or nil).
+func (i *Interpreter) VisitLoopExpr(expr *LoopExpr) (result interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			breakError, ok := r.(*BreakError)
+			if !ok || !breakError.targets(expr.label) {
+				panic(r) // re-panic if it's not a break of this loop
+			}
+			result = breakError.value
+		}
+	}()
+
+	for {
+		i.checkInterrupt(expr.keyword)
+		switch signal := i.execute(expr.body).(type) {
+		case *ReturnError:
+			return signal
+		case *ContinueError:
+			if !signal.targets(expr.label) {
+				return signal
+			}
+		}
+	}
+}
+
 // VisitGroupingExpr evaluates a grouping expression.
 // Evaluates the expression inside the parentheses.
 func (i *Interpreter) VisitGroupingExpr(expr *GroupingExpr) interface{} {
@@ -82,16 +320,22 @@ func (i *Interpreter) VisitGroupingExpr(expr *GroupingExpr) interface{} {
 }
 
 // VisitUnaryExpr evaluates a unary expression.
-// Handles negation (-) and logical not (!) operators.
+// Handles negation (-), logical not (!) and bitwise not (~) operators.
 func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) interface{} {
-	right := i.evaluate(expr.right)
+	return i.unaryOperation(expr.operator, i.evaluate(expr.right))
+}
 
-	switch expr.operator.tokenType {
+// unaryOperation applies a unary operator to an already evaluated operand.
+func (i *Interpreter) unaryOperation(operator *Token, right interface{}) interface{} {
+	switch operator.tokenType {
 	case BANG:
 		return !i.isTruthy(right)
 	case MINUS:
-		i.checkNumberOperand(expr.operator, right)
+		i.checkNumberOperand(operator, right)
 		return -right.(float64)
+	case TILDE:
+		i.checkNumberOperand(operator, right)
+		return i.integerResult(operator, ^i.integerOperand(operator, right.(float64)))
 	}
 
 	return nil
@@ -100,18 +344,20 @@ func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) interface{} {
 // VisitBinaryExpr evaluates a binary expression.
 // Handles arithmetic, comparison, and equality operators.
 func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) interface{} {
-	left := i.evaluate(expr.left)
-	right := i.evaluate(expr.right)
+	return i.binaryOperation(expr.operator, i.evaluate(expr.left), i.evaluate(expr.right))
+}
 
-	switch expr.operator.tokenType {
+// binaryOperation applies a binary operator to already evaluated operands.
+func (i *Interpreter) binaryOperation(operator *Token, left, right interface{}) interface{} {
+	switch operator.tokenType {
 	case MINUS:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) - right.(float64)
+		i.checkNumberOperands(operator, left, right)
+		return i.checkArithmeticOverflow(operator, left.(float64), right.(float64), left.(float64)-right.(float64))
 	case PLUS:
 		// number + number.
 		if l, ok := left.(float64); ok {
 			if r, ok := right.(float64); ok {
-				return l + r
+				return i.checkArithmeticOverflow(operator, l, r, l+r)
 			}
 		}
 
@@ -136,68 +382,384 @@ func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) interface{} {
 			}
 		}
 
-		log.Fatal(ReportExit(expr.operator.line, "", "Operands must be two numbers or two strings."))
+		panic(NewRuntimeErrorAt(operator, "Operands must be two numbers or two strings."))
 	case SLASH:
-		i.checkNumberOperands(expr.operator, left, right)
+		i.checkNumberOperands(operator, left, right)
 		// assert no division by 0.
 		if left.(float64) == 0 || right.(float64) == 0 {
-			log.Fatal(ReportExit(expr.operator.line, "", "Division by 0 is not allowed."))
+			panic(NewRuntimeErrorAt(operator, "Division by 0 is not allowed."))
 		}
 		return left.(float64) / right.(float64)
 	case STAR:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) * right.(float64)
+		i.checkNumberOperands(operator, left, right)
+		return i.checkArithmeticOverflow(operator, left.(float64), right.(float64), left.(float64)*right.(float64))
+	case STAR_STAR:
+		i.checkNumberOperands(operator, left, right)
+		return i.checkArithmeticOverflow(operator, left.(float64), right.(float64), math.Pow(left.(float64), right.(float64)))
 	case GREATER:
-		i.checkNumberOperands(expr.operator, left, right)
+		i.checkNumberOperands(operator, left, right)
 		return left.(float64) > right.(float64)
 	case GREATER_EQUAL:
-		i.checkNumberOperands(expr.operator, left, right)
+		i.checkNumberOperands(operator, left, right)
 		return left.(float64) >= right.(float64)
 	case LESS:
-		i.checkNumberOperands(expr.operator, left, right)
+		i.checkNumberOperands(operator, left, right)
 		return left.(float64) < right.(float64)
 	case LESS_EQUAL:
-		i.checkNumberOperands(expr.operator, left, right)
+		i.checkNumberOperands(operator, left, right)
 		return left.(float64) <= right.(float64)
 	case BANG_EQUAL:
 		return !i.isEqual(left, right)
 	case EQUAL_EQUAL:
 		return i.isEqual(left, right)
+	case AMPERSAND, PIPE, CARET, LESS_LESS, GREATER_GREATER:
+		i.checkNumberOperands(operator, left, right)
+		return i.bitwiseOperation(operator, i.integerOperand(operator, left.(float64)), i.integerOperand(operator, right.(float64)))
 	}
 
 	return nil
 }
 
+// toInteger truncates a number towards zero for the bitwise operators.
+func toInteger(value float64) int64 {
+	return int64(value)
+}
+
+// bitwiseOperation applies a bitwise or shift operator to operands that
+// have already been converted to integers.
+func (i *Interpreter) bitwiseOperation(operator *Token, left, right int64) float64 {
+	switch operator.tokenType {
+	case AMPERSAND:
+		return i.integerResult(operator, left&right)
+	case PIPE:
+		return i.integerResult(operator, left|right)
+	case CARET:
+		return i.integerResult(operator, left^right)
+	}
+
+	if right < 0 {
+		panic(NewRuntimeErrorAt(operator, "Shift count must not be negative."))
+	}
+	if operator.tokenType == LESS_LESS {
+		if i.checkedIntegers && (right >= 64 || (left<<right)>>right != left) {
+			panic(NewRuntimeErrorAt(operator, fmt.Sprintf("Integer overflow in %v'%v'%v: bits were shifted out.", YELLOW, operator.lexeme, RESET)))
+		}
+		return i.integerResult(operator, left<<right)
+	}
+	return float64(left >> right)
+}
+
 func (i *Interpreter) VisitCallExpr(expr *CallExpr) interface{} {
 	callee := i.evaluate(expr.callee)
 
-	var arguments []interface{}
+	// Arguments are pushed onto a stack shared by all calls and popped once the
+	// call returns, so recursive calls don't allocate a fresh slice each time.
+	// Nested calls made while evaluating arguments push above and pop back
+	// down before the next argument is appended.
+	base := len(i.argumentStack)
 	for _, argument := range expr.arguments {
-		arguments = append(arguments, i.evaluate(argument))
+		value := i.evaluate(argument)
+		i.argumentStack = append(i.argumentStack, value)
 	}
+	arguments := i.argumentStack[base:len(i.argumentStack):len(i.argumentStack)]
 
 	if _, ok := callee.(LoxCallable); !ok {
-		log.Fatal(ReportExit(expr.paren.line, "", "Can't call non-callable object."))
+		panic(NewRuntimeErrorAt(expr.paren, "Can't call non-callable object."))
 	}
 
 	function := callee.(LoxCallable)
+	i.checkInterrupt(expr.paren)
+	i.countCall(function)
 	if len(arguments) != function.arity() {
-		log.Fatal(ReportExit(expr.paren.line, "", fmt.Sprintf("Expected %v arguments but got %v.", function.arity(), len(arguments))))
+		arguments = i.adjustArguments(expr.paren, function, base, arguments)
+	}
+	if events != nil {
+		i.logNativeCall(expr, function)
+	}
+	// Frames aren't popped by a defer: after a runtime error they must still
+	// be there when Interpret builds the stack trace.
+	i.pushFrame(function, expr.paren.line)
+	result := function.call(i, arguments)
+	i.callStack = i.callStack[:len(i.callStack)-1]
+	clear(i.argumentStack[base:])
+	i.argumentStack = i.argumentStack[:base]
+	return result
+}
+
+// countCall adds a call of function to the statistics and call counts.
+func (i *Interpreter) countCall(function LoxCallable) {
+	i.stats.calls++
+	if i.callCounts != nil {
+		i.callCounts[callableName(function)]++
+	}
+}
+
+// adjustArguments handles a call with the wrong number of arguments. It's
+// an error unless optional arguments are enabled, in which case calls to
+// Lox functions and classes get nil for missing trailing arguments and
+// drop extra ones. Natives always need the exact count.
+func (i *Interpreter) adjustArguments(paren *Token, function LoxCallable, base int, arguments []interface{}) []interface{} {
+	_, isFunction := function.(*LoxFunction)
+	_, isClass := function.(*LoxClass)
+	if !i.optionalArguments || !(isFunction || isClass) {
+		panic(NewRuntimeErrorAt(paren, fmt.Sprintf("Expected %v arguments but got %v.", function.arity(), len(arguments))))
+	}
+
+	arity := function.arity()
+	for len(i.argumentStack)-base < arity {
+		i.argumentStack = append(i.argumentStack, nil)
+	}
+	return i.argumentStack[base : base+arity : base+arity]
+}
+
+// VisitSliceExpr evaluates 'object[start:end]', the characters of a string
+// or the elements of a list from start up to but not including end, as a
+// new string or list. A missing start is 0 and a missing end the length.
+func (i *Interpreter) VisitSliceExpr(expr *SliceExpr) interface{} {
+	object := i.evaluate(expr.object)
+	var start, end interface{}
+	if expr.start != nil {
+		start = i.evaluate(expr.start)
 	}
-	return function.call(i, arguments)
+	if expr.end != nil {
+		end = i.evaluate(expr.end)
+	}
+	switch object := object.(type) {
+	case string:
+		characters := []rune(object)
+		low, high := checkSlice(expr.bracket, start, end, len(characters))
+		return string(characters[low:high])
+	case *LoxList:
+		low, high := checkSlice(expr.bracket, start, end, len(object.elements))
+		return NewLoxList(slices.Clone(object.elements[low:high]))
+	}
+
+	panic(NewRuntimeErrorAt(expr.bracket, "Only strings and lists can be sliced."))
+}
+
+// callWithFrame calls function from Go code, e.g. a native taking a
+// callback or a getter, recording the call made on line for stack traces.
+// Like a call in the script it is counted and can be interrupted.
+func (i *Interpreter) callWithFrame(function LoxCallable, arguments []interface{}, line int) interface{} {
+	i.checkInterruptOnLine(line)
+	i.countCall(function)
+	i.pushFrame(function, line)
+	result := function.call(i, arguments)
+	i.callStack = i.callStack[:len(i.callStack)-1]
+	return result
+}
+
+// catchRuntimeError runs body and returns the runtime error it stops with,
+// or nil. Like Interpret after an error, it leaves the interpreter as it
+// was before body ran, recording the stack trace first so the error can be
+// raised again. An interruption such as a time limit isn't caught, so the
+// script still stops.
+func (i *Interpreter) catchRuntimeError(body func()) (err *RuntimeError) {
+	environment := i.environment
+	argumentBase := len(i.argumentStack)
+	callBase := len(i.callStack)
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeError, ok := r.(*RuntimeError)
+			if !ok || i.interruption.Load() != nil {
+				panic(r)
+			}
+			if runtimeError.line == LINE_UNKNOWN && len(i.callStack) > callBase {
+				// Natives don't know where they were called from.
+				runtimeError.line = i.callStack[len(i.callStack)-1].line
+			}
+			if runtimeError.stack == nil {
+				runtimeError.stack = i.stackTrace(runtimeError.line, 0)
+			}
+			i.environment = environment
+			clear(i.argumentStack[argumentBase:])
+			i.argumentStack = i.argumentStack[:argumentBase]
+			clear(i.callStack[callBase:])
+			i.callStack = i.callStack[:callBase]
+			err = runtimeError
+		}
+	}()
+	body()
+	return nil
+}
+
+// VisitIndexExpr evaluates 'object[index]', a character of a string, an
+// element of a list or a value stored in a map.
+func (i *Interpreter) VisitIndexExpr(expr *IndexExpr) interface{} {
+	object := i.evaluate(expr.object)
+	index := i.evaluate(expr.index)
+	switch object := object.(type) {
+	case string:
+		return stringIndex(expr.bracket, object, index)
+	case *LoxList:
+		return object.elements[checkIndex(expr.bracket, index, len(object.elements))]
+	case *LoxMap:
+		value, ok := object.get(index)
+		if !ok {
+			panic(NewRuntimeErrorAt(expr.bracket, fmt.Sprintf("Map has no key %v%v%v.", YELLOW, stringifyValue(index), RESET)))
+		}
+		return value
+	}
+
+	panic(NewRuntimeErrorAt(expr.bracket, "Only strings, lists and maps can be indexed."))
+}
+
+// VisitIndexSetExpr evaluates 'object[index] = value', which replaces an
+// element of a list or stores a value in a map.
+func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) interface{} {
+	object := i.evaluate(expr.object)
+	index := i.evaluate(expr.index)
+	value := i.evaluate(expr.value)
+
+	switch object := object.(type) {
+	case *LoxList:
+		object.elements[checkIndex(expr.bracket, index, len(object.elements))] = value
+		return value
+	case *LoxMap:
+		object.set(checkMapKey(expr.bracket, index), value)
+		return value
+	}
+
+	panic(NewRuntimeErrorAt(expr.bracket, "Only lists and maps support index assignment."))
+}
+
+// VisitMapExpr evaluates a map literal. Entries are evaluated in order, and
+// a repeated key keeps its last value.
+func (i *Interpreter) VisitMapExpr(expr *MapExpr) interface{} {
+	entries := NewLoxMap()
+	for index, key := range expr.keys {
+		key := checkMapKey(expr.brace, i.evaluate(key))
+		entries.set(key, i.evaluate(expr.values[index]))
+	}
+	return entries
+}
+
+// VisitFunctionExpr evaluates an anonymous function, closing over the
+// current environment.
+func (i *Interpreter) VisitFunctionExpr(expr *FunctionExpr) interface{} {
+	return NewLoxFunction(expr.declaration, i.environment, false)
+}
+
+// VisitListExpr evaluates a list literal.
+func (i *Interpreter) VisitListExpr(expr *ListExpr) interface{} {
+	elements := make([]interface{}, len(expr.elements))
+	for index, element := range expr.elements {
+		elements[index] = i.evaluate(element)
+	}
+	return NewLoxList(elements)
+}
+
+// VisitGetExpr evaluates a property access on an instance.
+func (i *Interpreter) VisitGetExpr(expr *GetExpr) interface{} {
+	object := i.evaluate(expr.object)
+	if object == nil && expr.optional {
+		panic(nilReceiver{})
+	}
+	if instance, ok := object.(*LoxInstance); ok {
+		return i.callGetter(instance.get(expr.name), expr.name)
+	}
+
+	if enum, ok := object.(*LoxEnum); ok {
+		return enum.get(expr.name)
+	}
+	if member, ok := object.(*LoxEnumMember); ok {
+		return member.get(expr.name)
+	}
+
+	if class, ok := object.(*LoxClass); ok {
+		if method := class.findStaticMethod(expr.name.lexeme); method != nil {
+			return method
+		}
+		panic(NewRuntimeErrorAt(expr.name, fmt.Sprintf("Undefined static method %v'%v'%v.", YELLOW, expr.name.lexeme, RESET)))
+	}
+
+	panic(NewRuntimeErrorAt(expr.name, "Only instances, classes and enums have properties."))
+}
+
+// nilReceiver is raised by 'object?.name' when object is nil, and caught by
+// the OptionalChainExpr around it to skip the rest of the chain.
+type nilReceiver struct{}
+
+// VisitOptionalChainExpr evaluates a chain starting with 'object?.name',
+// which is nil without evaluating the rest of the chain when object is nil.
+func (i *Interpreter) VisitOptionalChainExpr(expr *OptionalChainExpr) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(nilReceiver); !ok {
+				panic(r) // re-panic if it's not this chain's nil receiver
+			}
+			value = nil
+		}
+	}()
+	return i.evaluate(expr.expression)
+}
+
+// VisitSetExpr evaluates an assignment to an instance field.
+func (i *Interpreter) VisitSetExpr(expr *SetExpr) interface{} {
+	object := i.evaluate(expr.object)
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		panic(NewRuntimeErrorAt(expr.name, "Only instances have fields."))
+	}
+
+	value := i.evaluate(expr.value)
+	instance.set(expr.name, value)
+	return value
+}
+
+// VisitSuperExpr evaluates a 'super.method' access.
+// Looks the method up starting at the enclosing class's superclass.
+func (i *Interpreter) VisitSuperExpr(expr *SuperExpr) interface{} {
+	superclass := i.environment.get(expr.keyword).(*LoxClass)
+	this := &Token{tokenType: THIS, lexeme: "this", line: expr.keyword.line, column: expr.keyword.column}
+	object := i.environment.get(this).(*LoxInstance)
+
+	method := superclass.findMethod(expr.method.lexeme)
+	if method == nil {
+		panic(NewRuntimeErrorAt(expr.method, fmt.Sprintf("Undefined property %v'%v'%v.", YELLOW, expr.method.lexeme, RESET)))
+	}
+	return i.callGetter(method.bind(object), expr.method)
+}
+
+// callGetter runs value if it is a getter method, read through name, and
+// returns its result. Any other property value is returned as is.
+func (i *Interpreter) callGetter(value interface{}, name *Token) interface{} {
+	if method, ok := value.(*LoxFunction); ok && method.declaration.isGetter {
+		return i.callWithFrame(method, nil, name.line)
+	}
+	return value
+}
+
+// VisitThisExpr evaluates 'this' inside a method.
+func (i *Interpreter) VisitThisExpr(expr *ThisExpr) interface{} {
+	return i.environment.get(expr.keyword)
+}
+
+// logNativeCall records a call to a function implemented in Go.
+func (i *Interpreter) logNativeCall(expr *CallExpr, function LoxCallable) {
+	switch function.(type) {
+	case *LoxFunction, *LoxClass:
+		return
+	}
+
+	name := function.String()
+	if variable, ok := expr.callee.(*VariableExpr); ok {
+		name = variable.name.lexeme
+	}
+	events.emit("native_call", map[string]interface{}{"name": name, "line": expr.paren.line})
 }
 
 // VisitVariableExpr evaluates a variable expression.
 // Looks up the variable's value in the current environment.
 func (i *Interpreter) VisitVariableExpr(expr *VariableExpr) interface{} {
-	return i.environment.get(expr.name)
+	return i.environment.getIn(expr.name, i.globals)
 }
 
 // VisitAssignExpr evaluates an assignment expression.
 // Updates the variable's value in the current environment.
 func (i *Interpreter) VisitAssignExpr(expr *AssignExpr) interface{} {
 	value := i.evaluate(expr.value)
-	i.environment.assign(expr.name, value)
+	i.environment.assignIn(expr.name, value, i.globals)
 	return value
 }
 
@@ -216,8 +778,45 @@ func (i *Interpreter) VisitIfStmt(stmt *IfStmt) interface{} {
 	return nil
 }
 
+// VisitClassStmt executes a class declaration.
+// Defines the class in the current environment with its methods.
+func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) interface{} {
+	var superclass *LoxClass
+	if stmt.superclass != nil {
+		class, ok := i.evaluate(stmt.superclass).(*LoxClass)
+		if !ok {
+			panic(NewRuntimeErrorAt(stmt.superclass.name, "Superclass must be a class."))
+		}
+		superclass = class
+	}
+
+	// Methods of a subclass close over an extra scope holding 'super'.
+	closure := i.environment
+	if superclass != nil {
+		closure = NewEnclosingEnvironment(i.environment)
+		closure.define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range stmt.methods {
+		methods[method.name.lexeme] = NewLoxFunction(method, closure, method.name.lexeme == "init")
+	}
+
+	staticMethods := make(map[string]*LoxFunction)
+	for _, method := range stmt.staticMethods {
+		staticMethods[method.name.lexeme] = NewLoxFunction(method, closure, false)
+	}
+
+	class := NewLoxClass(stmt.name.lexeme, superclass, methods)
+	class.staticMethods = staticMethods
+	i.checkNativeShadowing(stmt.name)
+	i.environment.define(stmt.name.lexeme, class)
+	return nil
+}
+
 func (i *Interpreter) VisitFunctionStmt(stmt *FunctionStmt) interface{} {
-	function := NewLoxFunction(stmt, i.environment)
+	function := NewLoxFunction(stmt, i.environment, false)
+	i.checkNativeShadowing(stmt.name)
 	i.environment.define(stmt.name.lexeme, function)
 	return nil
 }
@@ -231,7 +830,14 @@ func (i *Interpreter) VisitPrintStmt(stmt *PrintStmt) interface{} {
 		token = v.name
 	}
 	value := i.evaluate(stmt.expression)
-	fmt.Println(stringify(token, value))
+	text := stringify(token, value)
+	events.emit("print", map[string]interface{}{"text": text})
+	outputLock.Lock()
+	fmt.Fprintln(i.out, text)
+	if i.flushPrints {
+		i.out.Flush()
+	}
+	outputLock.Unlock()
 	return nil
 }
 
@@ -252,6 +858,51 @@ func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) interface{} {
 	return &ReturnError{value: value}
 }
 
+// VisitDestructureStmt executes 'var a, b = list;' and 'var [a, b] = list;',
+// defining one variable per element, and 'var {x, y} = object;', defining
+// variables from the map entries or instance properties of the same names.
+// A list must have exactly as many elements as there are names.
+func (i *Interpreter) VisitDestructureStmt(stmt *DestructureStmt) interface{} {
+	values := make([]interface{}, len(stmt.names))
+	if stmt.pattern != nil && stmt.pattern.tokenType == LEFT_BRACE {
+		object := i.evaluate(stmt.initializer)
+		for index, name := range stmt.names {
+			values[index] = i.destructureProperty(stmt.equals, object, name)
+		}
+	} else if stmt.initializer != nil {
+		list, ok := i.evaluate(stmt.initializer).(*LoxList)
+		if !ok {
+			panic(NewRuntimeErrorAt(stmt.equals, "Can only unpack a list into several variables."))
+		}
+		if len(list.elements) != len(values) {
+			panic(NewRuntimeErrorAt(stmt.equals, fmt.Sprintf("Expected %v values to unpack, got %v.", len(values), len(list.elements))))
+		}
+		copy(values, list.elements)
+	}
+
+	for index, name := range stmt.names {
+		i.checkNativeShadowing(name)
+		i.environment.define(name.lexeme, values[index])
+	}
+	return nil
+}
+
+// destructureProperty reads the value named by name out of a map or
+// instance for 'var {name} = object;'.
+func (i *Interpreter) destructureProperty(equals *Token, object interface{}, name *Token) interface{} {
+	switch object := object.(type) {
+	case *LoxMap:
+		value, ok := object.get(name.lexeme)
+		if !ok {
+			panic(NewRuntimeErrorAt(name, fmt.Sprintf("Map has no key %v%v%v.", YELLOW, stringifyValue(name.lexeme), RESET)))
+		}
+		return value
+	case *LoxInstance:
+		return i.callGetter(object.get(name), name)
+	}
+	panic(NewRuntimeErrorAt(equals, fmt.Sprintf("Can only destructure maps and instances with %v'{}'%v.", YELLOW, RESET)))
+}
+
 // VisitVarStmt executes a variable declaration statement.
 // Defines a new variable in the current environment.
 func (i *Interpreter) VisitVarStmt(stmt *VarStmt) interface{} {
@@ -260,45 +911,130 @@ func (i *Interpreter) VisitVarStmt(stmt *VarStmt) interface{} {
 		value = i.evaluate(stmt.initializer)
 	}
 
-	i.environment.define(stmt.name.lexeme, value)
+	i.checkNativeShadowing(stmt.name)
+	if stmt.constant {
+		i.environment.defineConstant(stmt.name.lexeme, value)
+	} else {
+		i.environment.define(stmt.name.lexeme, value)
+	}
 	return nil
 }
 
 func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) interface{} {
 	defer func() {
 		if r := recover(); r != nil {
-			if _, ok := r.(*BreakError); !ok {
-				panic(r) // re-panic if it's not a break
+			if breakError, ok := r.(*BreakError); !ok || !breakError.targets(stmt.label) {
+				panic(r) // re-panic if it's not a break of this loop
 			}
 		}
 	}()
 
 	var result interface{}
 	for i.isTruthy(i.evaluate(stmt.condition)) {
+		i.checkInterrupt(stmt.keyword)
 		result = i.execute(stmt.body)
+		switch signal := result.(type) {
+		case *ReturnError:
+			return result
+		case *ContinueError:
+			if !signal.targets(stmt.label) {
+				return result // continue an outer loop
+			}
+			result = nil
+		}
+		if stmt.increment != nil {
+			i.evaluate(stmt.increment)
+		}
 	}
 	return result
 }
 
+// VisitUsingStmt executes a 'using' resource block.
+// The resource is closed when the block exits, including on return and break.
+func (i *Interpreter) VisitUsingStmt(stmt *UsingStmt) interface{} {
+	value := i.evaluate(stmt.initializer)
+	resource, ok := value.(LoxResource)
+	if !ok {
+		panic(NewRuntimeErrorAt(stmt.keyword, fmt.Sprintf("Value of %v'%v'%v is not a closable resource.", YELLOW, stmt.name.lexeme, RESET)))
+	}
+	defer resource.close()
+
+	environment := NewEnclosingEnvironment(i.environment)
+	environment.define(stmt.name.lexeme, value)
+	return i.executeBlock([]Stmt{stmt.body}, environment)
+}
+
 // VisitBlockStmt executes a block statement.
-// Creates a new environment for the block's scope.
+// Creates a new environment for the block's scope when it declares anything.
 func (i *Interpreter) VisitBlockStmt(stmt *BlockStmt) interface{} {
+	// The fast path steps the counter without checking it for overflow or
+	// recording the loop's scope, so those modes run the loop as written.
+	if stmt.numericFor != nil && !i.checkedIntegers && !i.postMortem {
+		return i.executeNumericFor(stmt.numericFor)
+	}
+	if !stmt.scoped {
+		return i.executeBlock(stmt.statements, i.environment)
+	}
 	return i.executeBlock(stmt.statements, NewEnclosingEnvironment(i.environment))
 }
 
 func (i *Interpreter) VisitBreakStmt(stmt *BreakStmt) interface{} {
-	panic(&BreakError{})
+	var value interface{}
+	if stmt.value != nil {
+		value = i.evaluate(stmt.value)
+	}
+	breakError := &BreakError{value: value}
+	if stmt.label != nil {
+		breakError.label = stmt.label.lexeme
+	}
+	panic(breakError)
 }
 
 // BreakError is used to handle break statements
-type BreakError struct{}
+type BreakError struct {
+	value interface{} // Value yielded by a 'break value;' inside a loop expression
+	label string      // Label of the loop to exit, or "" for the innermost one
+}
 
 func (e *BreakError) Error() string {
 	return "Break statement"
 }
 
+// targets reports whether the break exits the loop with the given label.
+func (e *BreakError) targets(label *Token) bool {
+	return e.label == "" || (label != nil && label.lexeme == e.label)
+}
+
+// VisitContinueStmt ends the current loop iteration. Like a return, the
+// ContinueError is passed back up as a statement result until the loop
+// sees it.
+func (i *Interpreter) VisitContinueStmt(stmt *ContinueStmt) interface{} {
+	if stmt.label != nil {
+		return &ContinueError{label: stmt.label.lexeme}
+	}
+	return continueError
+}
+
+// ContinueError is used to handle continue statements
+type ContinueError struct {
+	label string // Label of the loop to continue, or "" for the innermost one
+}
+
+// continueError is shared by every unlabeled continue; it carries no state.
+var continueError = &ContinueError{}
+
+func (e *ContinueError) Error() string {
+	return "Continue statement"
+}
+
+// targets reports whether the continue applies to the loop with the given label.
+func (e *ContinueError) targets(label *Token) bool {
+	return e.label == "" || (label != nil && label.lexeme == e.label)
+}
+
 // execute executes a statement.
 func (i *Interpreter) execute(stmt Stmt) interface{} {
+	i.stats.statements++
 	return stmt.accept(i)
 }
 
@@ -311,10 +1047,14 @@ func (i *Interpreter) executeBlock(statements []Stmt, environment *Environment)
 	}()
 
 	i.environment = environment
+	if i.postMortem {
+		defer recordFailureScope(environment)
+	}
 	var result interface{}
 	for _, statement := range statements {
 		result = i.execute(statement)
-		if _, ok := result.(*ReturnError); ok {
+		switch result.(type) {
+		case *ReturnError, *ContinueError:
 			return result
 		}
 	}
@@ -323,9 +1063,23 @@ func (i *Interpreter) executeBlock(statements []Stmt, environment *Environment)
 
 // evaluate evaluates an expression and returns its value.
 func (i *Interpreter) evaluate(expr Expr) interface{} {
+	if i.iterative {
+		return i.evaluateIterative(expr)
+	}
 	return expr.accept(i)
 }
 
+// evaluateIn evaluates an expression inside the given environment.
+func (i *Interpreter) evaluateIn(expr Expr, environment *Environment) interface{} {
+	previous := i.environment
+	defer func() {
+		i.environment = previous
+	}()
+
+	i.environment = environment
+	return i.evaluate(expr)
+}
+
 // isTruthy determines if a value is considered true in Lox.
 // nil and false are falsey, everything else is truthy.
 func (i *Interpreter) isTruthy(object interface{}) bool {
@@ -381,7 +1135,7 @@ func (i *Interpreter) checkNumberOperand(operator *Token, operand interface{}) {
 	if _, ok := operand.(float64); ok {
 		return
 	}
-	log.Fatal(ReportExit(operator.line, "", "Operand must be a number."))
+	panic(NewRuntimeErrorAt(operator, "Operand must be a number."))
 }
 
 // checkNumberOperands verifies that both operands are numbers.
@@ -392,14 +1146,18 @@ func (i *Interpreter) checkNumberOperands(operator *Token, left, right interface
 			return
 		}
 	}
-	log.Fatal(ReportExit(operator.line, "", "Operands must be numbers."))
+	panic(NewRuntimeErrorAt(operator, "Operands must be numbers."))
 }
 
 // stringify converts a value to a string representation.
 // Handles nil, numbers, and strings.
 func stringify(token *Token, object interface{}) string {
 	if object == nil {
-		log.Fatal(ReportExit(token.line, "", fmt.Sprintf("Variable %v'%v'%v is undefined.", YELLOW, token.lexeme, RESET)))
+		// Only a variable that was never given a value is an error.
+		if token == nil {
+			return "nil"
+		}
+		panic(NewRuntimeErrorAt(token, fmt.Sprintf("Variable %v'%v'%v is undefined.", YELLOW, token.lexeme, RESET)))
 	}
 
 	if v, ok := object.(float64); ok {
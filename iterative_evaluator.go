@@ -0,0 +1,91 @@
+// Package main implements a Lox language interpreter
+package main
+
+// evalFrame is one pending expression on the iterative evaluator's stack.
+type evalFrame struct {
+	expr  Expr // Expression being evaluated
+	stage int  // How many of its operands have been evaluated so far
+}
+
+// evaluateIterative evaluates an expression with an explicit stack instead of
+// Go recursion. Operator trees (binary, unary, logical and grouping
+// expressions) are walked iteratively, so very deep expressions don't grow
+// the Go stack; any other node is handed to its visitor method as usual.
+func (i *Interpreter) evaluateIterative(root Expr) interface{} {
+	frames := []evalFrame{{expr: root}}
+	var values []interface{}
+
+	pop := func() interface{} {
+		value := values[len(values)-1]
+		values = values[:len(values)-1]
+		return value
+	}
+
+	for len(frames) > 0 {
+		top := len(frames) - 1
+		frame := frames[top]
+
+		switch expr := frame.expr.(type) {
+		case *LiteralExpr:
+			values = append(values, expr.value)
+			frames = frames[:top]
+
+		case *GroupingExpr:
+			if frame.stage == 0 {
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.expression})
+			} else {
+				frames = frames[:top]
+			}
+
+		case *UnaryExpr:
+			if frame.stage == 0 {
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.right})
+			} else {
+				values = append(values, i.unaryOperation(expr.operator, pop()))
+				frames = frames[:top]
+			}
+
+		case *BinaryExpr:
+			switch frame.stage {
+			case 0:
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.left})
+			case 1:
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.right})
+			default:
+				right := pop()
+				left := pop()
+				values = append(values, i.binaryOperation(expr.operator, left, right))
+				frames = frames[:top]
+			}
+
+		case *LogicalExpr:
+			switch frame.stage {
+			case 0:
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.left})
+			case 1:
+				// Short-circuit: the left value is the result.
+				left := values[len(values)-1]
+				if (expr.operator.tokenType == OR) == i.isTruthy(left) {
+					frames = frames[:top]
+					break
+				}
+				pop()
+				frames[top].stage++
+				frames = append(frames, evalFrame{expr: expr.right})
+			default:
+				frames = frames[:top]
+			}
+
+		default:
+			values = append(values, expr.accept(i))
+			frames = frames[:top]
+		}
+	}
+
+	return values[0]
+}
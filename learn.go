@@ -0,0 +1,234 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// lessonStep is one exercise of a lesson. check is given the interpreter
+// after the user's input has run, along with the value of the input's last
+// statement, and reports whether the exercise was solved.
+type lessonStep struct {
+	instruction string
+	hint        string
+	check       func(i *Interpreter, value interface{}) bool
+}
+
+// lesson is a group of exercises on one topic, run by 'jlox learn'.
+type lesson struct {
+	title string
+	intro string
+	steps []lessonStep
+}
+
+var lessons = []lesson{
+	{
+		title: "Variables",
+		intro: "Variables are declared with 'var' and hold numbers, strings, booleans or nil.",
+		steps: []lessonStep{
+			{
+				instruction: `Declare a variable named greeting holding the string "hello".`,
+				hint:        `var greeting = "hello";`,
+				check: func(i *Interpreter, value interface{}) bool {
+					return lessonGlobal(i, "greeting") == "hello"
+				},
+			},
+			{
+				instruction: `Evaluate greeting joined with ", world" using the + operator.`,
+				hint:        `greeting + ", world";`,
+				check: func(i *Interpreter, value interface{}) bool {
+					return value == "hello, world"
+				},
+			},
+			{
+				instruction: "Assign greeting a new value: the number 42.",
+				hint:        "greeting = 42;",
+				check: func(i *Interpreter, value interface{}) bool {
+					return lessonGlobal(i, "greeting") == 42.0
+				},
+			},
+		},
+	},
+	{
+		title: "Control flow",
+		intro: "'if', 'while' and 'for' work like they do in C. Blocks go in braces.",
+		steps: []lessonStep{
+			{
+				instruction: "Declare total as 0, then use a for loop to add the numbers 1 to 10 to it.",
+				hint:        "var total = 0; for (var n = 1; n <= 10; n = n + 1) total = total + n;",
+				check: func(i *Interpreter, value interface{}) bool {
+					return lessonGlobal(i, "total") == 55.0
+				},
+			},
+			{
+				instruction: `Use if/else to set a variable size to "big" if total is over 50, and "small" otherwise.`,
+				hint:        `var size; if (total > 50) size = "big"; else size = "small";`,
+				check: func(i *Interpreter, value interface{}) bool {
+					return lessonGlobal(i, "size") == "big"
+				},
+			},
+		},
+	},
+	{
+		title: "Functions",
+		intro: "Functions are declared with 'fun' and are values like any other.",
+		steps: []lessonStep{
+			{
+				instruction: "Define a function square(n) that returns n times n.",
+				hint:        "fun square(n) { return n * n; }",
+				check: func(i *Interpreter, value interface{}) bool {
+					return lessonCall(i, "square", 4.0) == 16.0
+				},
+			},
+			{
+				instruction: "Call square with 12.",
+				hint:        "square(12);",
+				check: func(i *Interpreter, value interface{}) bool {
+					return value == 144.0
+				},
+			},
+			{
+				instruction: "Define makeAdder(n), which returns a function that adds n to its argument.",
+				hint:        "fun makeAdder(n) { fun add(x) { return x + n; } return add; }",
+				check: func(i *Interpreter, value interface{}) bool {
+					add, ok := lessonCall(i, "makeAdder", 2.0).(LoxCallable)
+					return ok && add.arity() == 1 && add.call(i, []interface{}{3.0}) == 5.0
+				},
+			},
+		},
+	},
+	{
+		title: "Classes",
+		intro: "Classes hold methods; 'init' runs when an instance is created and 'this' is the instance.",
+		steps: []lessonStep{
+			{
+				instruction: "Define a class Point whose init(x, y) stores x and y as fields.",
+				hint:        "class Point { init(x, y) { this.x = x; this.y = y; } }",
+				check: func(i *Interpreter, value interface{}) bool {
+					point, ok := lessonCall(i, "Point", 1.0, 2.0).(*LoxInstance)
+					return ok && point.fields["x"] == 1.0 && point.fields["y"] == 2.0
+				},
+			},
+			{
+				instruction: "Create a Point at (3, 4) in a variable named p.",
+				hint:        "var p = Point(3, 4);",
+				check: func(i *Interpreter, value interface{}) bool {
+					point, ok := lessonGlobal(i, "p").(*LoxInstance)
+					return ok && point.fields["x"] == 3.0 && point.fields["y"] == 4.0
+				},
+			},
+			{
+				instruction: "Evaluate p.x * p.x + p.y * p.y.",
+				hint:        "p.x * p.x + p.y * p.y;",
+				check: func(i *Interpreter, value interface{}) bool {
+					return value == 25.0
+				},
+			},
+		},
+	},
+}
+
+// lessonGlobal returns the value of a global variable, or nil if it isn't
+// defined.
+func lessonGlobal(i *Interpreter, name string) interface{} {
+	if !i.globals.has(name) {
+		return nil
+	}
+	return i.globals.get(&Token{tokenType: IDENTIFIER, lexeme: name})
+}
+
+// lessonCall calls the global function or class name with arguments and
+// returns its result, or nil if it isn't callable with that many arguments
+// or fails.
+func lessonCall(i *Interpreter, name string, arguments ...interface{}) (result interface{}) {
+	callee, ok := lessonGlobal(i, name).(LoxCallable)
+	if !ok || callee.arity() != len(arguments) {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*RuntimeError); !ok {
+				panic(r)
+			}
+			result = nil
+		}
+	}()
+	return callee.call(i, arguments)
+}
+
+// runLearn walks through the lessons in a REPL session. Every input runs in
+// the same interpreter, so earlier answers stay defined, and the value of
+// each input is echoed back before it's checked against the exercise.
+// ':hint' shows an answer, ':skip' moves on and ':quit' stops.
+func (lox *Lox) runLearn() {
+	reader := bufio.NewReader(os.Stdin)
+	lox.interpreter = lox.newInterpreter()
+	lox.interpreter.flushPrints = true // Prints must come before the next prompt.
+
+	fmt.Println("Welcome to Lox! Type Lox code at the prompt to solve each exercise.")
+	fmt.Println("Commands: :hint shows an answer, :skip skips an exercise, :quit stops.")
+	for number, lesson := range lessons {
+		fmt.Printf("\n%vLesson %v: %v%v\n%v\n", YELLOW, number+1, lesson.title, RESET, lesson.intro)
+		for _, step := range lesson.steps {
+			fmt.Printf("\n%v\n", step.instruction)
+			for solved := false; !solved; {
+				fmt.Print("> ")
+				line, err := reader.ReadString('\n')
+				if err == io.EOF && line == "" {
+					fmt.Println()
+					return
+				} else if err != nil && err != io.EOF {
+					log.Fatal("Error reading input: ", err)
+				}
+
+				switch strings.TrimSpace(line) {
+				case "":
+					continue
+				case ":quit":
+					return
+				case ":hint":
+					fmt.Printf("Try: %v\n", step.hint)
+					continue
+				case ":skip":
+					solved = true
+					continue
+				}
+
+				value, ok := lox.runLesson(line)
+				if !ok {
+					continue
+				}
+				if value != nil {
+					fmt.Printf("=> %v\n", stringifyValue(value))
+				}
+				if solved = step.check(lox.interpreter, value); solved {
+					fmt.Println("Correct!")
+				} else {
+					fmt.Println("Not quite. Try again, or type :hint.")
+				}
+			}
+		}
+	}
+	fmt.Println("\nYou've finished every lesson. Happy hacking!")
+}
+
+// runLesson runs one line of input, reporting any errors. ok is false if
+// the input didn't run to completion.
+func (lox *Lox) runLesson(source string) (value interface{}, ok bool) {
+	statements, ok := load(source, lox)
+	if !ok {
+		return nil, false
+	}
+	value, err := lox.interpreter.Interpret(statements)
+	if err != nil {
+		lox.runtimeError(err.(*RuntimeError))
+		lox.hadRuntimeError = false
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,115 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// interruptRequest is the runtime error a script was asked to stop with.
+type interruptRequest struct {
+	message string
+	cause   error // Wrapped by the runtime error, e.g. context.Canceled
+}
+
+// interrupt asks a running script to stop with a runtime error carrying
+// message and wrapping cause. The script stops at its next loop iteration
+// or call, so even an infinite loop can be ended. It is safe to call from
+// another goroutine. The returned request is what clearInterrupt takes.
+func (i *Interpreter) interrupt(message string, cause error) *interruptRequest {
+	request := &interruptRequest{message: message, cause: cause}
+	i.interruption.Store(request)
+	return request
+}
+
+// clearInterrupt withdraws request if it's still pending, leaving any later
+// one in place.
+func (i *Interpreter) clearInterrupt(request *interruptRequest) {
+	i.interruption.CompareAndSwap(request, nil)
+}
+
+// checkInterrupt raises the runtime error requested by interrupt, if any,
+// at token. Loops and calls check it so every long-running script passes
+// through here regularly.
+func (i *Interpreter) checkInterrupt(token *Token) {
+	if request := i.interruption.Load(); request != nil {
+		err := NewRuntimeErrorAt(token, request.message)
+		err.cause = request.cause
+		panic(err)
+	}
+}
+
+// checkInterruptOnLine is checkInterrupt for Go code that only knows the
+// line it was called from.
+func (i *Interpreter) checkInterruptOnLine(line int) {
+	if request := i.interruption.Load(); request != nil {
+		err := NewRuntimeError(line, request.message)
+		err.cause = request.cause
+		panic(err)
+	}
+}
+
+// limitTime interrupts the script if it's still running after limit.
+// The returned function cancels the limit and clears any interruption it
+// caused, so the interpreter can run again.
+func (i *Interpreter) limitTime(limit time.Duration) (cancel func()) {
+	if limit <= 0 {
+		return func() {}
+	}
+	var request *interruptRequest
+	done := make(chan struct{})
+	timer := time.AfterFunc(limit, func() {
+		request = i.interrupt(fmt.Sprintf("Script exceeded its time limit of %v.", limit), context.DeadlineExceeded)
+		close(done)
+	})
+	return func() {
+		if !timer.Stop() {
+			<-done
+			i.clearInterrupt(request)
+		}
+	}
+}
+
+// pausePollInterval is how often pause checks for an interruption.
+const pausePollInterval = 10 * time.Millisecond
+
+// pause waits for d, stopping early with the runtime error requested by
+// interrupt if the script is interrupted meanwhile.
+func (i *Interpreter) pause(d time.Duration) {
+	for deadline := time.Now().Add(d); ; {
+		i.checkInterruptOnLine(LINE_UNKNOWN)
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return
+		}
+		time.Sleep(min(remaining, pausePollInterval))
+	}
+}
+
+// watchContext interrupts the script when ctx is cancelled, with an error
+// wrapping the cancellation's cause. The returned function stops watching
+// and clears any interruption it caused, so the interpreter can run again.
+func (i *Interpreter) watchContext(ctx context.Context) (stop func()) {
+	var request *interruptRequest
+	done := make(chan struct{})
+	stopWatching := context.AfterFunc(ctx, func() {
+		request = i.interrupt(cancellationMessage(ctx), context.Cause(ctx))
+		close(done)
+	})
+	return func() {
+		if !stopWatching() {
+			<-done
+			i.clearInterrupt(request)
+		}
+	}
+}
+
+// cancellationMessage is the runtime error message for a cancelled ctx.
+func cancellationMessage(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "Execution cancelled: deadline exceeded."
+	}
+	return "Execution cancelled."
+}
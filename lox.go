@@ -7,23 +7,74 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
-type Lox struct{}
+// Lox holds the configuration shared by every run of the interpreter.
+type Lox struct {
+	checkOnly             bool              // Stop after scanning and parsing, without executing
+	release               bool              // Skip requires/ensures contract checks
+	bufferOutput          bool              // Buffer printed output until the run finishes
+	iterative             bool              // Use the explicit-stack expression evaluator
+	forbidNativeShadowing bool              // Reject globals that shadow natives
+	dialect               *Dialect          // Keyword spellings for the scanner, nil for standard Lox
+	defines               Defines           // Compile-time constants from the command line
+	sandbox               bool              // Disallow remote imports and native plugins
+	optionalArguments     bool              // Let calls pass fewer or more arguments than declared
+	checkedIntegers       bool              // Report integer overflow and lossy truncation
+	postMortem            bool              // Open a REPL in the failing scope after a runtime error
+	timeLimit             time.Duration     // Stop each run after this long, 0 for no limit
+	maxCallDepth          int               // Calls that can nest before a stack overflow error, 0 for no limit
+	callCounts            map[string]int    // Calls per function name, nil unless counting
+	script                string            // Path of the script being run, "" for source from the command line
+	bundled               map[string][]byte // Imported scripts carried by a bundle, by import path
+
+	interpreter     *Interpreter // Interpreter shared by every run, created on first use
+	hadError        bool         // Whether a syntax error was reported
+	hadRuntimeError bool         // Whether a run stopped with a runtime error
+}
+
+// Exit codes, following the BSD sysexits.h conventions.
+const (
+	EXIT_USAGE       = 64 // The command line was used incorrectly
+	EXIT_DATAERR     = 65 // The script has syntax errors
+	EXIT_NOINPUT     = 66 // The script couldn't be read
+	EXIT_UNAVAILABLE = 69 // A dependency couldn't be fetched
+	EXIT_SOFTWARE    = 70 // The script stopped with a runtime error
+	EXIT_CANTCREAT   = 73 // An output file couldn't be created
+)
 
 func NewLox(hadError bool) *Lox {
-	return &Lox{}
+	return &Lox{hadError: hadError, maxCallDepth: defaultMaxCallDepth}
 }
 
 // run is the function that calls the interpreters interpreting functionalities.
-func (lox *Lox) run(source string) {
-	scanner := NewScanner(source, lox)
-	tokens := scanner.ScanTokens()
-	parser := NewParser(tokens)
-	statements := parser.Parse()
+// It returns the value of the last statement if that was an expression.
+func (lox *Lox) run(source string) (value interface{}) {
+	defer lox.recoverCrash(source)
+	statements, ok := load(source, lox)
+	if !ok {
+		lox.hadError = true
+		return nil
+	}
+	if lox.checkOnly {
+		return nil
+	}
 
-	interpreter := NewInterpreter()
-	interpreter.Interpret(statements)
+	if lox.interpreter == nil {
+		lox.interpreter = lox.newInterpreter()
+	}
+	if lox.script != "" {
+		lox.interpreter.enterScript(lox.script)
+	}
+	value, err := lox.interpreter.Interpret(statements)
+	lox.interpreter.flushOutput()
+	if err != nil {
+		lox.runtimeError(err.(*RuntimeError))
+		if lox.postMortem {
+			lox.runPostMortem(err.(*RuntimeError))
+		}
+	}
 
 	// fmt.Printf("\n%s%-15s%s %s%-50s%s %s%-50s%s\n\n",
 	// 	WHITE, "TOKEN ↓", RESET,
@@ -32,17 +83,193 @@ func (lox *Lox) run(source string) {
 	// for _, token := range tokens {
 	// 	fmt.Println(token.toString())
 	// }
+	return value
+}
+
+// newInterpreter creates an interpreter with the options set on lox,
+// followed by opts.
+func (lox *Lox) newInterpreter(opts ...Option) *Interpreter {
+	options := []Option{WithTimeLimit(lox.timeLimit), WithMaxCallDepth(lox.maxCallDepth), WithCallCounts(lox.callCounts), WithConstants(lox.defines), WithDialect(lox.dialect)}
+	if lox.bundled != nil {
+		options = append(options, WithBundledImports(lox.bundled))
+	}
+	if lox.release {
+		options = append(options, WithRelease())
+	}
+	if lox.bufferOutput {
+		options = append(options, WithBufferedOutput())
+	}
+	if lox.iterative {
+		options = append(options, WithIterative())
+	}
+	if lox.forbidNativeShadowing {
+		options = append(options, WithForbidNativeShadowing())
+	}
+	if lox.sandbox {
+		options = append(options, WithSandbox())
+	}
+	if lox.optionalArguments {
+		options = append(options, WithOptionalArguments())
+	}
+	if lox.checkedIntegers {
+		options = append(options, WithCheckedIntegers())
+	}
+	if lox.postMortem {
+		options = append(options, WithPostMortem())
+	}
+	return NewInterpreter(append(options, opts...)...)
+}
+
+// load runs the passes that happen before execution: scanning,
+// preprocessing, parsing and optimizing. ok is false if any of them reported
+// an error. lox may be nil for standard Lox without command-line defines.
+func load(source string, lox *Lox) (statements []Stmt, ok bool) {
+	var defines Defines
+	if lox != nil {
+		defines = lox.defines
+	}
+
+	scanner := NewScanner(source, lox)
+	preprocessor := NewPreprocessor(defines)
+	tokens := preprocessor.Process(scanner.ScanTokens())
+	parser := NewParser(tokens)
+	statements = parser.Parse()
+	if scanner.hadError || preprocessor.hadError || parser.hadError {
+		return nil, false
+	}
+
+	var globals *Environment
+	if lox != nil && lox.interpreter != nil {
+		globals = lox.interpreter.globals
+	}
+	optimizer := NewOptimizer(globals)
+	statements = optimizer.Optimize(statements)
+	return statements, !optimizer.hadError
 }
 
 // runFile is the function that runs when a valid file path is supplied
 // into the arguments.
 func (lox *Lox) runFile(path string) {
-	bytes, err := os.ReadFile(path)
+	source, err := readScript(path)
 	if err != nil {
-		log.Fatal("Failed to read file")
+		log.Print(err)
+		lox.exit(EXIT_NOINPUT)
+	}
+
+	if resolved, err := resolveScriptPath(path); err == nil {
+		lox.script = resolved
+	}
+	lox.runScript(string(source))
+}
+
+// runScript runs a whole script, exiting if it has an error.
+func (lox *Lox) runScript(source string) {
+	lox.run(source)
+	if lox.hadError {
+		lox.exit(EXIT_DATAERR)
+	}
+	if lox.hadRuntimeError {
+		lox.exit(EXIT_SOFTWARE)
+	}
+}
+
+// runExpression evaluates an expression given with '-p' and prints its
+// value, exiting if it has an error. The trailing ';' may be left out.
+func (lox *Lox) runExpression(source string) {
+	source = strings.TrimSpace(source)
+	if !strings.HasSuffix(source, ";") {
+		source += ";"
+	}
+	value := lox.run(source)
+	if lox.hadError {
+		lox.exit(EXIT_DATAERR)
+	}
+	if lox.hadRuntimeError {
+		lox.exit(EXIT_SOFTWARE)
+	}
+	if lox.checkOnly {
+		return
+	}
+	if value == nil {
+		fmt.Println("nil")
+	} else {
+		fmt.Println(stringify(nil, value))
+	}
+}
+
+// runLines runs source once for each line of input, for '-n', with the
+// line (without its line ending) in the global 'line' and its number,
+// starting at 1, in 'NR'. The script is parsed once and runs in the same
+// interpreter for every line. It exits on the first error.
+func (lox *Lox) runLines(source string, input io.Reader) {
+	statements, ok := load(source, lox)
+	if !ok {
+		lox.exit(EXIT_DATAERR)
+	}
+	if lox.checkOnly {
+		return
+	}
+	if lox.interpreter == nil {
+		lox.interpreter = lox.newInterpreter()
+	}
+
+	reader := bufio.NewReader(input)
+	for number := 1; ; number++ {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		} else if err != nil && err != io.EOF {
+			log.Fatal("Error reading input: ", err)
+		}
+
+		lox.interpreter.globals.define("line", strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		lox.interpreter.globals.define("NR", float64(number))
+		_, runErr := lox.interpreter.Interpret(statements)
+		if runErr != nil {
+			lox.interpreter.flushOutput()
+			lox.runtimeError(runErr.(*RuntimeError))
+			lox.exit(EXIT_SOFTWARE)
+		}
+	}
+	lox.interpreter.flushOutput()
+}
+
+// runtimeError reports a runtime error that stopped a run.
+func (lox *Lox) runtimeError(err *RuntimeError) {
+	var stack []string
+	for _, frame := range err.stack {
+		stack = append(stack, fmt.Sprintf("%v:%v", frame.function, frame.line))
+	}
+	events.emit("error", map[string]interface{}{"line": err.line, "column": err.column, "where": "", "message": plainText(err.message), "stack": stack})
+	log.Print(err.Error())
+	lox.hadRuntimeError = true
+}
+
+// exit ends the process with the given status, closing the event log first.
+func (lox *Lox) exit(status int) {
+	lox.finish()
+	events.emit("end", map[string]interface{}{"status": status})
+	os.Exit(status)
+}
+
+// finish prints the reports requested for the end of the process.
+func (lox *Lox) finish() {
+	if lox.callCounts != nil {
+		flushStdout()
+		writeCallCounts(os.Stderr, lox.callCounts)
 	}
+}
 
-	lox.run(string(bytes))
+// runFiles runs several scripts one after another in the same process.
+// Unless isolated is set they share one interpreter, so globals defined by
+// earlier scripts (e.g. a preloaded library) are visible to later ones.
+func (lox *Lox) runFiles(paths []string, isolated bool) {
+	for _, path := range paths {
+		if isolated {
+			lox.interpreter = nil
+		}
+		lox.runFile(path)
+	}
 }
 
 // runPrompt is the function that runs when no arguments are passed in.
@@ -62,6 +289,14 @@ func (lox *Lox) runPrompt() {
 		}
 
 		line = strings.TrimSuffix(line, "\n")
+		if strings.TrimSpace(line) == ":reset" {
+			if lox.interpreter != nil {
+				lox.interpreter.ResetGlobals()
+			}
+			continue
+		}
 		lox.run(line)
+		lox.hadError = false
+		lox.hadRuntimeError = false
 	}
 }
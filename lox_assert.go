@@ -0,0 +1,20 @@
+// Package main implements a Lox language interpreter
+package main
+
+import "fmt"
+
+// defineAssertNative adds assert(condition, message), which stops with a
+// runtime error at the line of the call when condition is falsey. It
+// returns nil otherwise. The error can be caught like any other.
+func defineAssertNative(globals *Environment) {
+	globals.define("assert", NewNativeFunction("assert", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		if !interpreter.isTruthy(arguments[0]) {
+			message := "Assertion failed."
+			if arguments[1] != nil {
+				message = fmt.Sprintf("Assertion failed: %v", stringify(nil, arguments[1]))
+			}
+			panic(NewRuntimeError(LINE_UNKNOWN, message))
+		}
+		return nil
+	}))
+}
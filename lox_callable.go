@@ -4,4 +4,29 @@ type LoxCallable interface {
 	arity() int
 	call(interpreter *Interpreter, arguments []interface{}) interface{}
 	String() string
-}
\ No newline at end of file
+}
+
+// NativeFunction is a LoxCallable implemented in Go.
+// It lets simple natives be registered without declaring a new type for each one.
+type NativeFunction struct {
+	name     string
+	argCount int
+	fn       func(interpreter *Interpreter, arguments []interface{}) interface{}
+}
+
+// NewNativeFunction creates a new NativeFunction instance.
+func NewNativeFunction(name string, argCount int, fn func(interpreter *Interpreter, arguments []interface{}) interface{}) *NativeFunction {
+	return &NativeFunction{name: name, argCount: argCount, fn: fn}
+}
+
+func (n *NativeFunction) arity() int {
+	return n.argCount
+}
+
+func (n *NativeFunction) call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return n.fn(interpreter, arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
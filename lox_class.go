@@ -0,0 +1,69 @@
+package main
+
+// LoxClass is the runtime representation of a class declaration.
+// Calling a class creates a new instance of it.
+type LoxClass struct {
+	name          string
+	superclass    *LoxClass
+	methods       map[string]*LoxFunction
+	staticMethods map[string]*LoxFunction // Methods called on the class itself
+	builtinError  bool                    // Whether this is the built-in Error class
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{name: name, superclass: superclass, methods: methods}
+}
+
+// findMethod looks up a method on the class, then up its superclass chain.
+func (c *LoxClass) findMethod(name string) *LoxFunction {
+	if method, ok := c.methods[name]; ok {
+		return method
+	}
+
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+	return nil
+}
+
+// findStaticMethod looks up a static method on the class, then up its superclass chain.
+func (c *LoxClass) findStaticMethod(name string) *LoxFunction {
+	if method, ok := c.staticMethods[name]; ok {
+		return method
+	}
+
+	if c.superclass != nil {
+		return c.superclass.findStaticMethod(name)
+	}
+	return nil
+}
+
+func (c *LoxClass) call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	instance := NewLoxInstance(c)
+	if initializer := c.findMethod("init"); initializer != nil {
+		initializer.bind(instance).call(interpreter, arguments)
+	}
+	return instance
+}
+
+// arity is the arity of the class's initializer, or 0 without one.
+func (c *LoxClass) arity() int {
+	if initializer := c.findMethod("init"); initializer != nil {
+		return initializer.arity()
+	}
+	return 0
+}
+
+// isErrorClass reports whether the class is Error or a subclass of it.
+func (c *LoxClass) isErrorClass() bool {
+	for class := c; class != nil; class = class.superclass {
+		if class.builtinError {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LoxClass) String() string {
+	return c.name
+}
@@ -0,0 +1,59 @@
+// Package main implements a Lox language interpreter
+package main
+
+import "fmt"
+
+// LoxEnum is the runtime representation of an enum declaration. Its
+// members are read as properties, e.g. 'Color.RED'.
+type LoxEnum struct {
+	name    string
+	members []*LoxEnumMember // In declaration order
+}
+
+// LoxEnumMember is one member of an enum. Members are only equal to
+// themselves, and print as 'Enum.MEMBER'.
+type LoxEnumMember struct {
+	enum    *LoxEnum
+	name    string
+	ordinal int // Position in the declaration, starting at 0
+}
+
+// VisitEnumStmt defines an enum and its members.
+func (i *Interpreter) VisitEnumStmt(stmt *EnumStmt) interface{} {
+	enum := &LoxEnum{name: stmt.name.lexeme}
+	for ordinal, member := range stmt.members {
+		enum.members = append(enum.members, &LoxEnumMember{enum: enum, name: member.lexeme, ordinal: ordinal})
+	}
+	i.checkNativeShadowing(stmt.name)
+	i.environment.define(stmt.name.lexeme, enum)
+	return nil
+}
+
+// get returns the member called name.
+func (e *LoxEnum) get(name *Token) interface{} {
+	for _, member := range e.members {
+		if member.name == name.lexeme {
+			return member
+		}
+	}
+	panic(NewRuntimeErrorAt(name, fmt.Sprintf("Enum %v'%v'%v has no member %v'%v'%v.", YELLOW, e.name, RESET, YELLOW, name.lexeme, RESET)))
+}
+
+func (e *LoxEnum) String() string {
+	return "enum " + e.name
+}
+
+// get returns the 'name' or 'ordinal' property of the member.
+func (m *LoxEnumMember) get(name *Token) interface{} {
+	switch name.lexeme {
+	case "name":
+		return m.name
+	case "ordinal":
+		return float64(m.ordinal)
+	}
+	panic(NewRuntimeErrorAt(name, fmt.Sprintf("Undefined property %v'%v'%v.", YELLOW, name.lexeme, RESET)))
+}
+
+func (m *LoxEnumMember) String() string {
+	return m.enum.name + "." + m.name
+}
@@ -0,0 +1,96 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoxResource is implemented by runtime values that hold an external resource.
+// A 'using' statement closes the resource when its block exits.
+type LoxResource interface {
+	close()
+}
+
+// LoxFile is an open file handle returned by the open() native.
+type LoxFile struct {
+	path   string        // Path the file was opened from
+	file   *os.File      // Underlying OS file
+	reader *bufio.Reader // Buffered reader used by readLine()
+	closed bool          // Whether close() has already run
+}
+
+// NewLoxFile creates a new LoxFile instance for an opened file.
+func NewLoxFile(path string, file *os.File) *LoxFile {
+	return &LoxFile{
+		path:   path,
+		file:   file,
+		reader: bufio.NewReader(file),
+	}
+}
+
+// close closes the underlying file. Closing twice is a no-op.
+func (f *LoxFile) close() {
+	if f.closed {
+		return
+	}
+	f.closed = true
+	f.file.Close()
+}
+
+// readLine reads the next line without its line ending, or nil at end of file.
+func (f *LoxFile) readLine() interface{} {
+	if f.closed {
+		panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("Can't read from closed file %v'%v'%v.", YELLOW, f.path, RESET)))
+	}
+	line, err := f.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil
+	}
+	if err != nil && err != io.EOF {
+		panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("Failed to read %v'%v'%v: %v", YELLOW, f.path, RESET, err)))
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func (f *LoxFile) String() string {
+	return "<file " + f.path + ">"
+}
+
+// defineFileNatives registers the file natives.
+func defineFileNatives(globals *Environment) {
+	globals.define("open", NewNativeFunction("open", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		path, ok := arguments[0].(string)
+		if !ok {
+			panic(NewRuntimeError(LINE_UNKNOWN, "File path must be a string."))
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("Failed to open file: %v", err)))
+		}
+		return NewLoxFile(path, file)
+	}))
+	globals.define("readLine", NewNativeFunction("readLine", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		return checkFile(arguments[0]).readLine()
+	}))
+	globals.define("close", NewNativeFunction("close", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		resource, ok := arguments[0].(LoxResource)
+		if !ok {
+			panic(NewRuntimeError(LINE_UNKNOWN, "Can only close resources."))
+		}
+		resource.close()
+		return nil
+	}))
+}
+
+// checkFile verifies that a native argument is a file.
+func checkFile(value interface{}) *LoxFile {
+	file, ok := value.(*LoxFile)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, "Expected a file."))
+	}
+	return file
+}
@@ -1,12 +1,24 @@
 package main
 
+import (
+	"fmt"
+)
+
 type LoxFunction struct {
-	declaration *FunctionStmt
-	closure     *Environment
+	declaration   *FunctionStmt
+	closure       *Environment
+	isInitializer bool // Class 'init' methods always return 'this'
+}
+
+func NewLoxFunction(declaration *FunctionStmt, closure *Environment, isInitializer bool) *LoxFunction {
+	return &LoxFunction{declaration: declaration, closure: closure, isInitializer: isInitializer}
 }
 
-func NewLoxFunction(declaration *FunctionStmt, closure *Environment) *LoxFunction {
-	return &LoxFunction{declaration: declaration, closure: closure}
+// bind returns a copy of the method whose 'this' refers to instance.
+func (f *LoxFunction) bind(instance *LoxInstance) *LoxFunction {
+	environment := NewEnclosingEnvironment(f.closure)
+	environment.define("this", instance)
+	return NewLoxFunction(f.declaration, environment, f.isInitializer)
 }
 
 func (f *LoxFunction) call(interpreter *Interpreter, arguments []interface{}) interface{} {
@@ -15,11 +27,37 @@ func (f *LoxFunction) call(interpreter *Interpreter, arguments []interface{}) in
 		environment.define(param.lexeme, arguments[i])
 	}
 
+	if !interpreter.release {
+		for _, condition := range f.declaration.requires {
+			f.checkContract(interpreter, condition, environment, "Precondition")
+		}
+	}
+
+	var value interface{}
 	result := interpreter.executeBlock(f.declaration.body, environment)
 	if returnError, ok := result.(*ReturnError); ok {
-		return returnError.value
+		value = returnError.value
+	}
+
+	if !interpreter.release && len(f.declaration.ensures) > 0 {
+		environment.define("result", value)
+		for _, condition := range f.declaration.ensures {
+			f.checkContract(interpreter, condition, environment, "Postcondition")
+		}
+	}
+
+	if f.isInitializer {
+		return f.closure.values["this"]
+	}
+	return value
+}
+
+// checkContract evaluates a requires/ensures clause in the call's environment
+// and stops execution if it doesn't hold.
+func (f *LoxFunction) checkContract(interpreter *Interpreter, condition Expr, environment *Environment, kind string) {
+	if !interpreter.isTruthy(interpreter.evaluateIn(condition, environment)) {
+		panic(NewRuntimeErrorAt(f.declaration.name, fmt.Sprintf("%v failed for %v'%v'%v.", kind, YELLOW, f.declaration.name.lexeme, RESET)))
 	}
-	return nil
 }
 
 func (f *LoxFunction) arity() int {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoxInstance is an object created by calling a class.
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]interface{}
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{class: class, fields: make(map[string]interface{})}
+}
+
+// get returns a field, or a method of the instance's class.
+// Fields shadow methods with the same name.
+func (o *LoxInstance) get(name *Token) interface{} {
+	if value, ok := o.fields[name.lexeme]; ok {
+		return value
+	}
+
+	if method := o.class.findMethod(name.lexeme); method != nil {
+		return method.bind(o)
+	}
+
+	panic(NewRuntimeErrorAt(name, fmt.Sprintf("Undefined property %v'%v'%v.", YELLOW, name.lexeme, RESET)))
+}
+
+// set stores a field on the instance, creating it if needed.
+func (o *LoxInstance) set(name *Token, value interface{}) {
+	o.fields[name.lexeme] = value
+}
+
+func (o *LoxInstance) String() string {
+	if o.class.isErrorClass() {
+		return o.errorString()
+	}
+	return o.class.name + " instance"
+}
+
+// errorString formats an Error as its type and message, followed by the
+// stack it was thrown or raised from once it has one, e.g.
+// "RuntimeError: Operands must be numbers." and "    in f at line 3".
+func (o *LoxInstance) errorString() string {
+	kind := o.class.name
+	if text, ok := o.fields["type"].(string); ok {
+		kind = text
+	}
+	text := kind
+	if message := o.fields["message"]; message != nil {
+		text += ": " + stringify(nil, message)
+	}
+	if stack, ok := o.fields["stack"].(string); ok && stack != "" {
+		text += "\n    " + strings.ReplaceAll(stack, "\n", "\n    ")
+	}
+	return text
+}
@@ -0,0 +1,86 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// LoxList is a Lox list value, a growable sequence of values indexed from 0.
+type LoxList struct {
+	elements []interface{}
+}
+
+// NewLoxList creates a new LoxList holding elements.
+func NewLoxList(elements []interface{}) *LoxList {
+	return &LoxList{elements: elements}
+}
+
+func (l *LoxList) String() string {
+	return l.format(nil)
+}
+
+// format converts the list to a string. printing holds the collections
+// already being converted further out, so a list that contains itself
+// prints as [...] instead of recursing forever.
+func (l *LoxList) format(printing map[interface{}]bool) string {
+	if printing[l] {
+		return "[...]"
+	}
+	if printing == nil {
+		printing = make(map[interface{}]bool)
+	}
+	printing[l] = true
+	defer delete(printing, l)
+
+	var builder strings.Builder
+	builder.WriteString("[")
+	for index, element := range l.elements {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(stringifyNested(element, printing))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// defineListNatives adds len(), which works on strings, lists and maps, and
+// the natives that grow and shrink lists.
+func defineListNatives(globals *Environment) {
+	globals.define("len", NewNativeFunction("len", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		switch value := arguments[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(value))
+		case *LoxList:
+			return float64(len(value.elements))
+		case *LoxMap:
+			return float64(len(value.keys))
+		}
+		panic(NewRuntimeError(LINE_UNKNOWN, "len() expects a string, list or map."))
+	}))
+	globals.define("push", NewNativeFunction("push", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		list := checkList("push", arguments[0])
+		list.elements = append(list.elements, arguments[1])
+		return arguments[1]
+	}))
+	globals.define("pop", NewNativeFunction("pop", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		list := checkList("pop", arguments[0])
+		if len(list.elements) == 0 {
+			panic(NewRuntimeError(LINE_UNKNOWN, "Can't pop from an empty list."))
+		}
+		last := list.elements[len(list.elements)-1]
+		list.elements[len(list.elements)-1] = nil
+		list.elements = list.elements[:len(list.elements)-1]
+		return last
+	}))
+}
+
+// checkList verifies that a native argument is a list.
+func checkList(native string, value interface{}) *LoxList {
+	list, ok := value.(*LoxList)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, native+"() expects a list."))
+	}
+	return list
+}
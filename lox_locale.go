@@ -0,0 +1,67 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collators caches a collator per locale, since building one is much
+// slower than comparing two strings.
+var collators = struct {
+	sync.Mutex
+	byLocale map[language.Tag]*collate.Collator
+}{byLocale: map[language.Tag]*collate.Collator{}}
+
+// defineLocaleNatives adds the natives that compare and change the case of
+// strings following the rules of a locale, e.g. "de", "tr" or "sv-SE".
+func defineLocaleNatives(globals *Environment) {
+	globals.define("compareStrings", NewNativeFunction("compareStrings", 3, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		a, b := checkString("compareStrings", arguments[0]), checkString("compareStrings", arguments[1])
+		tag := checkLocale(arguments[2])
+
+		collators.Lock()
+		collator, ok := collators.byLocale[tag]
+		if !ok {
+			collator = collate.New(tag)
+			collators.byLocale[tag] = collator
+		}
+		result := collator.CompareString(a, b)
+		collators.Unlock()
+		return float64(result)
+	}))
+	globals.define("toUpper", NewNativeFunction("toUpper", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		text := checkString("toUpper", arguments[0])
+		return cases.Upper(checkLocale(arguments[1])).String(text)
+	}))
+	globals.define("toLower", NewNativeFunction("toLower", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		text := checkString("toLower", arguments[0])
+		return cases.Lower(checkLocale(arguments[1])).String(text)
+	}))
+}
+
+// checkString verifies that a native argument is a string.
+func checkString(native string, value interface{}) string {
+	text, ok := value.(string)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("%v() expects a string.", native)))
+	}
+	return text
+}
+
+// checkLocale parses a BCP 47 locale name such as "en-GB".
+func checkLocale(value interface{}) language.Tag {
+	name, ok := value.(string)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, "Locale must be a string such as \"en-GB\"."))
+	}
+	tag, err := language.Parse(name)
+	if err != nil {
+		panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("Unknown locale %v'%v'%v.", YELLOW, name, RESET)))
+	}
+	return tag
+}
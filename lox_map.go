@@ -0,0 +1,140 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LoxMap is a Lox dictionary value backed by a Go map.
+// Keys are iterated in insertion order so output is the same on every run.
+type LoxMap struct {
+	entries map[interface{}]interface{} // Map of keys (strings or numbers) to their values
+	keys    []interface{}               // Keys in the order they were first inserted
+}
+
+// NewLoxMap creates a new empty LoxMap instance.
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		entries: make(map[interface{}]interface{}),
+	}
+}
+
+// get returns the value stored under key and whether it was present.
+func (m *LoxMap) get(key interface{}) (interface{}, bool) {
+	value, ok := m.entries[key]
+	return value, ok
+}
+
+// set stores value under key, replacing any previous value.
+func (m *LoxMap) set(key interface{}, value interface{}) {
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = value
+}
+
+func (m *LoxMap) String() string {
+	return m.format(nil)
+}
+
+// format converts the map to a string. Like LoxList.format, a map that
+// contains itself prints as {...} where it appears inside itself.
+func (m *LoxMap) format(printing map[interface{}]bool) string {
+	if printing[m] {
+		return "{...}"
+	}
+	if printing == nil {
+		printing = make(map[interface{}]bool)
+	}
+	printing[m] = true
+	defer delete(printing, m)
+
+	var builder strings.Builder
+	builder.WriteString("{")
+	for index, key := range m.keys {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(fmt.Sprintf("%v: %v", stringifyValue(key), stringifyNested(m.entries[key], printing)))
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
+
+// remove deletes the value stored under key, reporting whether there was one.
+func (m *LoxMap) remove(key interface{}) bool {
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+	delete(m.entries, key)
+	m.keys = slices.DeleteFunc(m.keys, func(existing interface{}) bool { return existing == key })
+	return true
+}
+
+// checkMapKey verifies that a value can be used as a map key. Only strings
+// and numbers can, so keys compare by value.
+func checkMapKey(token *Token, key interface{}) interface{} {
+	switch key.(type) {
+	case string, float64:
+		return key
+	}
+	panic(NewRuntimeErrorAt(token, "Map keys must be strings or numbers."))
+}
+
+// defineMapNatives adds the natives for inspecting and changing maps.
+func defineMapNatives(globals *Environment) {
+	globals.define("keys", NewNativeFunction("keys", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		entries := checkMap("keys", arguments[0])
+		return NewLoxList(slices.Clone(entries.keys))
+	}))
+	globals.define("values", NewNativeFunction("values", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		entries := checkMap("values", arguments[0])
+		values := make([]interface{}, len(entries.keys))
+		for index, key := range entries.keys {
+			values[index] = entries.entries[key]
+		}
+		return NewLoxList(values)
+	}))
+	globals.define("has", NewNativeFunction("has", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		_, ok := checkMap("has", arguments[0]).get(arguments[1])
+		return ok
+	}))
+	globals.define("remove", NewNativeFunction("remove", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		return checkMap("remove", arguments[0]).remove(arguments[1])
+	}))
+}
+
+// checkMap verifies that a native argument is a map.
+func checkMap(native string, value interface{}) *LoxMap {
+	entries, ok := value.(*LoxMap)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, native+"() expects a map."))
+	}
+	return entries
+}
+
+// stringifyValue converts a value nested inside a collection to a string.
+// Unlike a top level print, nil is allowed here.
+func stringifyValue(value interface{}) string {
+	return stringifyNested(value, nil)
+}
+
+// stringifyNested is stringifyValue for a value inside the collections in
+// printing, which are still being converted.
+func stringifyNested(value interface{}, printing map[interface{}]bool) string {
+	if value == nil {
+		return "nil"
+	}
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	switch collection := value.(type) {
+	case *LoxList:
+		return collection.format(printing)
+	case *LoxMap:
+		return collection.format(printing)
+	}
+	return stringify(nil, value)
+}
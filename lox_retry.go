@@ -0,0 +1,58 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// defineRetryNatives adds sleep(ms) and retry(fn, attempts, delayMs).
+func defineRetryNatives(globals *Environment) {
+	globals.define("sleep", NewNativeFunction("sleep", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		interpreter.pause(checkMilliseconds("sleep", arguments[0]))
+		return nil
+	}))
+
+	// retry calls fn until it returns without a runtime error, at most
+	// attempts times, and returns its result. The delay between attempts
+	// starts at delayMs and doubles each time. If every attempt fails, the
+	// last attempt's error stops the script as usual.
+	globals.define("retry", NewNativeFunction("retry", 3, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		function, ok := arguments[0].(LoxCallable)
+		if !ok || function.arity() != 0 {
+			panic(NewRuntimeError(LINE_UNKNOWN, "retry() expects a function without parameters."))
+		}
+		attempts, ok := arguments[1].(float64)
+		if !ok || attempts != math.Trunc(attempts) || attempts < 1 {
+			panic(NewRuntimeError(LINE_UNKNOWN, "retry() expects a positive whole number of attempts."))
+		}
+		delay := checkMilliseconds("retry", arguments[2])
+
+		line := LINE_UNKNOWN
+		if calls := interpreter.callStack; len(calls) > 0 {
+			line = calls[len(calls)-1].line
+		}
+		for attempt := 1; attempt < int(attempts); attempt++ {
+			var result interface{}
+			err := interpreter.catchRuntimeError(func() {
+				result = interpreter.callWithFrame(function, nil, line)
+			})
+			if err == nil {
+				return result
+			}
+			interpreter.pause(delay)
+			delay *= 2
+		}
+		return interpreter.callWithFrame(function, nil, line)
+	}))
+}
+
+// checkMilliseconds converts a native's argument, a non-negative number of
+// milliseconds, to a duration.
+func checkMilliseconds(native string, value interface{}) time.Duration {
+	milliseconds, ok := value.(float64)
+	if !ok || milliseconds < 0 {
+		panic(NewRuntimeError(LINE_UNKNOWN, native+"() expects a non-negative number of milliseconds."))
+	}
+	return time.Duration(milliseconds * float64(time.Millisecond))
+}
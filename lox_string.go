@@ -0,0 +1,81 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// defineStringNatives adds the natives for working with characters.
+func defineStringNatives(globals *Environment) {
+	globals.define("ord", NewNativeFunction("ord", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		text, ok := arguments[0].(string)
+		if !ok || utf8.RuneCountInString(text) != 1 {
+			panic(NewRuntimeError(LINE_UNKNOWN, "ord() expects a one-character string."))
+		}
+		c, _ := utf8.DecodeRuneInString(text)
+		return float64(c)
+	}))
+	globals.define("chr", NewNativeFunction("chr", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		code, ok := arguments[0].(float64)
+		if !ok || code != math.Trunc(code) || code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+			panic(NewRuntimeError(LINE_UNKNOWN, "chr() expects a valid Unicode code point."))
+		}
+		return string(rune(code))
+	}))
+}
+
+// stringIndex returns the character at index of text as a one-character
+// string. Strings are indexed by character, not byte.
+func stringIndex(bracket *Token, text string, index interface{}) string {
+	length := utf8.RuneCountInString(text)
+	position := checkIndex(bracket, index, length)
+	if length == len(text) {
+		// Every character is a single byte, so the index is a byte offset.
+		return text[position : position+1]
+	}
+	for _, character := range text {
+		if position == 0 {
+			return string(character)
+		}
+		position--
+	}
+	return ""
+}
+
+// checkSlice verifies the bounds of a slice of a sequence of length
+// values, defaulting a nil start to 0 and a nil end to length.
+func checkSlice(bracket *Token, start, end interface{}, length int) (int, int) {
+	bound := func(value interface{}, missing int) int {
+		if value == nil {
+			return missing
+		}
+		index, ok := value.(float64)
+		if !ok || index != math.Trunc(index) {
+			panic(NewRuntimeErrorAt(bracket, "Slice bounds must be integers."))
+		}
+		if index < 0 || index > float64(length) {
+			panic(NewRuntimeErrorAt(bracket, fmt.Sprintf("Slice bound %v out of range for length %v.", index, length)))
+		}
+		return int(index)
+	}
+	low, high := bound(start, 0), bound(end, length)
+	if low > high {
+		panic(NewRuntimeErrorAt(bracket, fmt.Sprintf("Slice start %v is after its end %v.", low, high)))
+	}
+	return low, high
+}
+
+// checkIndex verifies that an index is an integer within a sequence of
+// length values, for the [] operator.
+func checkIndex(bracket *Token, value interface{}, length int) int {
+	index, ok := value.(float64)
+	if !ok || index != math.Trunc(index) {
+		panic(NewRuntimeErrorAt(bracket, "Index must be an integer."))
+	}
+	if index < 0 || index >= float64(length) {
+		panic(NewRuntimeErrorAt(bracket, fmt.Sprintf("Index %v out of bounds for length %v.", index, length)))
+	}
+	return int(index)
+}
@@ -0,0 +1,123 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LoxTypedArray is a fixed-size numeric array backed by a Go slice.
+// Elements are stored unboxed so large numeric datasets stay compact.
+type LoxTypedArray struct {
+	kind     string    // Name of the constructor that created the array
+	float64s []float64 // Backing storage for Float64Array
+	int32s   []int32   // Backing storage for Int32Array
+}
+
+// NewFloat64Array creates a new zeroed Float64Array with the given length.
+func NewFloat64Array(length int) *LoxTypedArray {
+	return &LoxTypedArray{kind: "Float64Array", float64s: make([]float64, length)}
+}
+
+// NewInt32Array creates a new zeroed Int32Array with the given length.
+func NewInt32Array(length int) *LoxTypedArray {
+	return &LoxTypedArray{kind: "Int32Array", int32s: make([]int32, length)}
+}
+
+// length returns the number of elements in the array.
+func (a *LoxTypedArray) length() int {
+	if a.int32s != nil {
+		return len(a.int32s)
+	}
+	return len(a.float64s)
+}
+
+// get returns the element at the given index as a Lox number.
+func (a *LoxTypedArray) get(index int) float64 {
+	if a.int32s != nil {
+		return float64(a.int32s[index])
+	}
+	return a.float64s[index]
+}
+
+// set stores a Lox number at the given index.
+// Int32Array elements are truncated towards zero.
+func (a *LoxTypedArray) set(index int, value float64) {
+	if a.int32s != nil {
+		a.int32s[index] = int32(value)
+		return
+	}
+	a.float64s[index] = value
+}
+
+func (a *LoxTypedArray) String() string {
+	var builder strings.Builder
+	builder.WriteString(a.kind)
+	builder.WriteString("[")
+	for index := 0; index < a.length(); index++ {
+		if index > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(stringify(nil, a.get(index)))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// defineTypedArrayNatives registers the typed array constructors and accessors.
+func defineTypedArrayNatives(globals *Environment) {
+	globals.define("Float64Array", NewNativeFunction("Float64Array", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		return NewFloat64Array(typedArrayLength(arguments[0]))
+	}))
+	globals.define("Int32Array", NewNativeFunction("Int32Array", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		return NewInt32Array(typedArrayLength(arguments[0]))
+	}))
+	globals.define("arrayLength", NewNativeFunction("arrayLength", 1, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		return float64(checkTypedArray(arguments[0]).length())
+	}))
+	globals.define("arrayGet", NewNativeFunction("arrayGet", 2, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		array := checkTypedArray(arguments[0])
+		return array.get(typedArrayIndex(array, arguments[1]))
+	}))
+	globals.define("arraySet", NewNativeFunction("arraySet", 3, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		array := checkTypedArray(arguments[0])
+		index := typedArrayIndex(array, arguments[1])
+		value, ok := arguments[2].(float64)
+		if !ok {
+			panic(NewRuntimeError(LINE_UNKNOWN, "Typed array values must be numbers."))
+		}
+		array.set(index, value)
+		return value
+	}))
+}
+
+// checkTypedArray verifies that a native argument is a typed array.
+func checkTypedArray(value interface{}) *LoxTypedArray {
+	array, ok := value.(*LoxTypedArray)
+	if !ok {
+		panic(NewRuntimeError(LINE_UNKNOWN, "Expected a typed array."))
+	}
+	return array
+}
+
+// typedArrayLength verifies that a constructor argument is a valid array length.
+func typedArrayLength(value interface{}) int {
+	length, ok := value.(float64)
+	if !ok || length < 0 || length != math.Trunc(length) {
+		panic(NewRuntimeError(LINE_UNKNOWN, "Typed array length must be a non-negative integer."))
+	}
+	return int(length)
+}
+
+// typedArrayIndex verifies that an index is an integer within the array bounds.
+func typedArrayIndex(array *LoxTypedArray, value interface{}) int {
+	index, ok := value.(float64)
+	if !ok || index != math.Trunc(index) {
+		panic(NewRuntimeError(LINE_UNKNOWN, "Typed array index must be an integer."))
+	}
+	if index < 0 || int(index) >= array.length() {
+		panic(NewRuntimeError(LINE_UNKNOWN, fmt.Sprintf("Index %v out of bounds for %v of length %v.", index, array.kind, array.length())))
+	}
+	return int(index)
+}
@@ -4,24 +4,151 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
+	"runtime/debug"
 )
 
 // main is the entry point of the Lox interpreter.
-// It supports two modes of operation:
-// 1. File execution: jlox [script]
-// 2. Interactive REPL: jlox
+// It supports ten modes of operation:
+// 1. File execution: jlox [flags] [script]
+// 2. One-liners from the command line: jlox [flags] -e 'source', -p 'expression' or -n 'source'
+// 3. Multiple files in one process: jlox [flags] run [--isolated] script...
+// 4. Self-check report: jlox doctor
+// 5. Self-contained executable: jlox bundle script -o app
+// 6. Vendor the dependencies listed in lox.mod: jlox get [-update]
+// 7. Interactive tutorial: jlox learn
+// 8. Operator precedence table as Markdown: jlox operators
+// 9. Check test scripts against their '// expect:' comments: jlox test [path...]
+// 10. Interactive REPL: jlox [flags]
+//
+// An executable written by 'jlox bundle' always runs its attached script.
 func main() {
 	// log.SetFlags(0) // Removes the date before any log.Fatal().
-	args := os.Args
+	checkOnly := flag.Bool("check-only", false, "Scan and parse the script without executing it.")
+	release := flag.Bool("release", false, "Skip requires/ensures contract checks.")
+	unbuffered := flag.Bool("unbuffered", false, "Flush output after every print, even when not writing to a terminal.")
+	iterative := flag.Bool("iterative", false, "Evaluate expressions with an explicit stack instead of Go recursion.")
+	logJSON := flag.String("log-json", "", "Write runtime events as JSON lines to this file ('-' for stderr).")
+	forbidNativeShadowing := flag.Bool("forbid-native-shadowing", false, "Make defining a global with the same name as a native an error.")
+	defines := Defines{}
+	flag.Var(defines, "D", "Define a compile-time constant as NAME=value (repeatable).")
+	optionalArgs := flag.Bool("optional-args", false, "Pass nil for missing trailing arguments and ignore extra ones instead of reporting an arity error.")
+	checkedInts := flag.Bool("checked-ints", false, "Make integer overflow and truncating a fractional operand of a bitwise operator runtime errors.")
+	postMortem := flag.Bool("post-mortem", false, "After a runtime error, open a REPL in the scope where it happened.")
+	countCalls := flag.Bool("count-calls", false, "Print how many times each function was called when the process exits.")
+	maxCallDepth := flag.Int("max-call-depth", defaultMaxCallDepth, "Stop the script with a 'Stack overflow.' runtime error when calls nest deeper than this (0 for no limit, which lets runaway recursion crash jlox).")
+	timeout := flag.Duration("timeout", 0, "Stop the script with a runtime error after this long, e.g. 2s (0 for no limit).")
+	sandbox := flag.Bool("sandbox", false, "Disallow remote imports and native plugins.")
+	dialectPath := flag.String("dialect", "", "Load keyword aliases from this JSON dialect file.")
+	execute := flag.String("e", "", "Run this source code instead of a script.")
+	printExpression := flag.String("p", "", "Evaluate this expression and print its value.")
+	eachLine := flag.String("n", "", "Run this source code once for each line of stdin, with the line in 'line' and its number in 'NR'.")
+	flag.Parse()
+
+	// Deep recursion is limited by maxCallDepth rather than Go's smaller default stack.
+	debug.SetMaxStack(maxGoStack)
+
+	// Errors are logged to stderr; flush buffered prints first so they stay in order.
+	log.SetOutput(flushingWriter{writer: os.Stderr})
+
+	if *logJSON == "-" {
+		events = NewEventLog(os.Stderr)
+	} else if *logJSON != "" {
+		file, err := os.Create(*logJSON)
+		if err != nil {
+			log.Fatal("Failed to create event log: ", err)
+		}
+		defer file.Close()
+		events = NewEventLog(file)
+	}
+
+	args := flag.Args()
+	events.emit("start", map[string]interface{}{"args": args})
 	lox := NewLox(false)
-	if len(args) > 2 {
-		log.Fatal("Usage: jlox [script]")
-		os.Exit(64)
-	} else if len(args) == 2 {
-		lox.runFile(args[1])
+	lox.checkOnly = *checkOnly
+	lox.release = *release
+	lox.iterative = *iterative
+	lox.forbidNativeShadowing = *forbidNativeShadowing
+	if *dialectPath != "" {
+		dialect, err := LoadDialect(*dialectPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		lox.dialect = dialect
+	}
+	lox.defines = defines
+	lox.sandbox = *sandbox
+	lox.optionalArguments = *optionalArgs
+	lox.checkedIntegers = *checkedInts
+	lox.postMortem = *postMortem
+	lox.timeLimit = *timeout
+	lox.maxCallDepth = *maxCallDepth
+	if *countCalls {
+		lox.callCounts = map[string]int{}
+	}
+	lox.bufferOutput = !*unbuffered && !isInteractive(os.Stdout)
+	if contents, ok := readBundle(); ok {
+		lox.script = contents.Name
+		lox.bundled = contents.Imports
+		lox.runScript(string(contents.Script))
+	} else if oneLiners := countNonEmpty(*execute, *printExpression, *eachLine); oneLiners > 0 {
+		if len(args) > 0 || oneLiners > 1 {
+			log.Print("Usage: jlox [flags] -e 'source' | -p 'expression' | -n 'source'")
+			os.Exit(EXIT_USAGE)
+		}
+		if *execute != "" {
+			lox.runScript(*execute)
+		} else if *printExpression != "" {
+			lox.runExpression(*printExpression)
+		} else {
+			lox.runLines(*eachLine, os.Stdin)
+		}
+	} else if len(args) > 0 && args[0] == "run" {
+		runFlags := flag.NewFlagSet("run", flag.ExitOnError)
+		isolated := runFlags.Bool("isolated", false, "Give each script its own global environment.")
+		runFlags.Parse(args[1:])
+		if runFlags.NArg() == 0 {
+			log.Print("Usage: jlox [flags] run [--isolated] script...")
+			os.Exit(EXIT_USAGE)
+		}
+		lox.runFiles(runFlags.Args(), *isolated)
+	} else if len(args) > 0 && args[0] == "bundle" {
+		lox.runBundleCommand(args[1:])
+	} else if len(args) > 0 && args[0] == "get" {
+		lox.runGetCommand(args[1:])
+	} else if len(args) > 0 && args[0] == "learn" {
+		lox.runLearn()
+	} else if len(args) > 0 && args[0] == "operators" {
+		writeOperatorTable(os.Stdout)
+	} else if len(args) > 0 && args[0] == "test" {
+		if !lox.runTestCommand(args[1:]) {
+			os.Exit(1)
+		}
+	} else if len(args) > 0 && args[0] == "doctor" {
+		if !lox.runDoctor() {
+			os.Exit(1)
+		}
+	} else if len(args) > 1 {
+		log.Print("Usage: jlox [flags] [script]")
+		os.Exit(EXIT_USAGE)
+	} else if len(args) == 1 {
+		lox.runFile(args[0])
 	} else {
 		lox.runPrompt()
 	}
+	lox.finish()
+	events.emit("end", map[string]interface{}{"status": 0})
+}
+
+// countNonEmpty returns how many of values aren't empty.
+func countNonEmpty(values ...string) int {
+	count := 0
+	for _, value := range values {
+		if value != "" {
+			count++
+		}
+	}
+	return count
 }
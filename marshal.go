@@ -0,0 +1,371 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ToLox converts a Go value into a Lox value so it can be passed to a script,
+// e.g. returned from a native registered with RegisterNative.
+// Numbers become float64, slices and arrays become lists, and maps and
+// structs become maps. Struct fields are keyed by name, or by their `lox`
+// tag; a tag of "-" skips the field. Lox values are returned unchanged.
+func ToLox(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, bool, float64, string, *LoxList, *LoxMap, *LoxInstance, LoxCallable:
+		return value, nil
+	}
+	return toLox(reflect.ValueOf(value))
+}
+
+func toLox(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return ToLox(value.Elem().Interface())
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return nil, nil
+		}
+		elements := make([]interface{}, value.Len())
+		for index := range elements {
+			element, err := ToLox(value.Index(index).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[index] = element
+		}
+		return NewLoxList(elements), nil
+	case reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return mapToLox(value)
+	case reflect.Struct:
+		entries := NewLoxMap()
+		for _, field := range reflect.VisibleFields(value.Type()) {
+			name, ok := loxFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldValue, err := ToLox(value.FieldByIndex(field.Index).Interface())
+			if err != nil {
+				return nil, err
+			}
+			entries.set(name, fieldValue)
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("can't convert a Go %v to a Lox value", value.Type())
+}
+
+// mapToLox converts a Go map with string or number keys. Go doesn't order
+// map entries, so keys are sorted to keep the Lox map's order stable.
+func mapToLox(value reflect.Value) (interface{}, error) {
+	type entry struct {
+		key   interface{}
+		value reflect.Value
+	}
+	var entries []entry
+	for iter := value.MapRange(); iter.Next(); {
+		key, err := toLox(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case string, float64:
+		default:
+			return nil, fmt.Errorf("can't convert a Go %v to a Lox map: keys must be strings or numbers", value.Type())
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return lessMapKey(entries[a].key, entries[b].key)
+	})
+
+	result := NewLoxMap()
+	for _, entry := range entries {
+		element, err := ToLox(entry.value.Interface())
+		if err != nil {
+			return nil, err
+		}
+		result.set(entry.key, element)
+	}
+	return result, nil
+}
+
+// lessMapKey orders numbers before strings, then each by value.
+func lessMapKey(a, b interface{}) bool {
+	aNumber, aIsNumber := a.(float64)
+	bNumber, bIsNumber := b.(float64)
+	switch {
+	case aIsNumber && bIsNumber:
+		return aNumber < bNumber
+	case aIsNumber != bIsNumber:
+		return aIsNumber
+	default:
+		return a.(string) < b.(string)
+	}
+}
+
+// FromLox stores a Lox value, e.g. the result of Interpret, in the Go value
+// target points to. It's the inverse of ToLox: lists fill slices and arrays,
+// and maps or instances fill maps and structs. Numbers only convert to an
+// integer type when they're whole and in range. Converting into an empty
+// interface gives plain Go values: []interface{} for lists, and
+// map[string]interface{} for maps with only string keys.
+func FromLox(value interface{}, target interface{}) error {
+	pointer := reflect.ValueOf(target)
+	if pointer.Kind() != reflect.Pointer || pointer.IsNil() {
+		return fmt.Errorf("FromLox needs a non-nil pointer, not %T", target)
+	}
+	return fromLox(value, pointer.Elem())
+}
+
+func fromLox(value interface{}, target reflect.Value) error {
+	mismatch := func() error {
+		return fmt.Errorf("can't convert a Lox %v to a Go %v", loxTypeName(value), target.Type())
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		if target.NumMethod() > 0 {
+			if value == nil {
+				target.SetZero()
+				return nil
+			}
+			if !reflect.TypeOf(value).Implements(target.Type()) {
+				return mismatch()
+			}
+			target.Set(reflect.ValueOf(value))
+			return nil
+		}
+		if plain := plainGoValue(value); plain == nil {
+			target.SetZero()
+		} else {
+			target.Set(reflect.ValueOf(plain))
+		}
+		return nil
+	case reflect.Pointer:
+		if value == nil {
+			target.SetZero()
+			return nil
+		}
+		if reflect.TypeOf(value) == target.Type() {
+			target.Set(reflect.ValueOf(value))
+			return nil
+		}
+		element := reflect.New(target.Type().Elem())
+		if err := fromLox(value, element.Elem()); err != nil {
+			return err
+		}
+		target.Set(element)
+		return nil
+	case reflect.Bool:
+		boolean, ok := value.(bool)
+		if !ok {
+			return mismatch()
+		}
+		target.SetBool(boolean)
+		return nil
+	case reflect.String:
+		str, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		target.SetString(str)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		number, ok := value.(float64)
+		if !ok {
+			return mismatch()
+		}
+		target.SetFloat(number)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := value.(float64)
+		if !ok {
+			return mismatch()
+		}
+		if number != math.Trunc(number) || target.OverflowInt(int64(number)) || number < math.MinInt64 || number >= math.MaxInt64 {
+			return fmt.Errorf("can't convert %v to a Go %v", number, target.Type())
+		}
+		target.SetInt(int64(number))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number, ok := value.(float64)
+		if !ok {
+			return mismatch()
+		}
+		if number != math.Trunc(number) || number < 0 || number >= math.MaxUint64 || target.OverflowUint(uint64(number)) {
+			return fmt.Errorf("can't convert %v to a Go %v", number, target.Type())
+		}
+		target.SetUint(uint64(number))
+		return nil
+	case reflect.Slice, reflect.Array:
+		if value == nil && target.Kind() == reflect.Slice {
+			target.SetZero()
+			return nil
+		}
+		list, ok := value.(*LoxList)
+		if !ok {
+			return mismatch()
+		}
+		if target.Kind() == reflect.Slice {
+			target.Set(reflect.MakeSlice(target.Type(), len(list.elements), len(list.elements)))
+		} else if target.Len() != len(list.elements) {
+			return fmt.Errorf("can't convert a Lox list of %v elements to a Go %v", len(list.elements), target.Type())
+		}
+		for index, element := range list.elements {
+			if err := fromLox(element, target.Index(index)); err != nil {
+				return fmt.Errorf("element %v: %w", index, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		if value == nil {
+			target.SetZero()
+			return nil
+		}
+		entries, ok := value.(*LoxMap)
+		if !ok {
+			return mismatch()
+		}
+		result := reflect.MakeMapWithSize(target.Type(), len(entries.keys))
+		for _, key := range entries.keys {
+			goKey := reflect.New(target.Type().Key()).Elem()
+			if err := fromLox(key, goKey); err != nil {
+				return fmt.Errorf("key %v: %w", stringifyValue(key), err)
+			}
+			goValue := reflect.New(target.Type().Elem()).Elem()
+			if err := fromLox(entries.entries[key], goValue); err != nil {
+				return fmt.Errorf("key %v: %w", stringifyValue(key), err)
+			}
+			result.SetMapIndex(goKey, goValue)
+		}
+		target.Set(result)
+		return nil
+	case reflect.Struct:
+		var lookup func(name string) (interface{}, bool)
+		switch source := value.(type) {
+		case *LoxMap:
+			lookup = func(name string) (interface{}, bool) { return source.get(name) }
+		case *LoxInstance:
+			lookup = func(name string) (interface{}, bool) {
+				field, ok := source.fields[name]
+				return field, ok
+			}
+		default:
+			return mismatch()
+		}
+		for _, field := range reflect.VisibleFields(target.Type()) {
+			name, ok := loxFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldValue, ok := lookup(name)
+			if !ok {
+				continue // Missing entries leave the field's zero value.
+			}
+			if err := fromLox(fieldValue, target.FieldByIndex(field.Index)); err != nil {
+				return fmt.Errorf("field %v: %w", name, err)
+			}
+		}
+		return nil
+	}
+	return mismatch()
+}
+
+// plainGoValue converts a Lox value to the Go value FromLox stores in an
+// empty interface.
+func plainGoValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *LoxList:
+		elements := make([]interface{}, len(value.elements))
+		for index, element := range value.elements {
+			elements[index] = plainGoValue(element)
+		}
+		return elements
+	case *LoxMap:
+		stringKeys := true
+		for _, key := range value.keys {
+			if _, ok := key.(string); !ok {
+				stringKeys = false
+			}
+		}
+		if stringKeys {
+			entries := make(map[string]interface{}, len(value.keys))
+			for _, key := range value.keys {
+				entries[key.(string)] = plainGoValue(value.entries[key])
+			}
+			return entries
+		}
+		entries := make(map[interface{}]interface{}, len(value.keys))
+		for _, key := range value.keys {
+			entries[key] = plainGoValue(value.entries[key])
+		}
+		return entries
+	}
+	return value
+}
+
+// loxFieldName returns the map key a struct field converts to, and false if
+// the field is unexported or tagged `lox:"-"`.
+func loxFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() || field.Anonymous {
+		return "", false
+	}
+	tag, _, _ := strings.Cut(field.Tag.Get("lox"), ",")
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		return field.Name, true
+	}
+	return tag, true
+}
+
+// loxTypeName names the type of a Lox value for error messages.
+func loxTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case *LoxInstance:
+		return "instance"
+	case *LoxEnum:
+		return "enum"
+	case *LoxEnumMember:
+		return "enum member"
+	case LoxCallable:
+		return "function"
+	}
+	return fmt.Sprintf("%T", value)
+}
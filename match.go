@@ -0,0 +1,82 @@
+// Package main implements a Lox language interpreter
+package main
+
+import "fmt"
+
+// MatchArm is one 'pattern -> value' arm of a match expression. An arm
+// with neither a pattern nor a type name matches any value.
+type MatchArm struct {
+	pattern  Expr   // Literal or enum member the subject must equal
+	typeName *Token // Type, class or enum the subject must belong to, for a type pattern
+	binding  *Token // Variable holding the subject in value, nil for none
+	value    Expr   // Value of the match when the arm is selected
+}
+
+// builtinTypes are the type names a pattern can use besides class names.
+var builtinTypes = map[string]bool{
+	"boolean":  true,
+	"number":   true,
+	"string":   true,
+	"list":     true,
+	"map":      true,
+	"instance": true,
+	"function": true,
+}
+
+// VisitMatchExpr evaluates the subject of a match once and then the value
+// of the first arm whose pattern it matches. It's a runtime error for no
+// arm to match.
+func (i *Interpreter) VisitMatchExpr(expr *MatchExpr) interface{} {
+	subject := i.evaluate(expr.subject)
+	for _, arm := range expr.arms {
+		if !i.matchesPattern(arm, subject) {
+			continue
+		}
+		if arm.binding == nil {
+			return i.evaluate(arm.value)
+		}
+		environment := NewEnclosingEnvironment(i.environment)
+		environment.define(arm.binding.lexeme, subject)
+		return i.evaluateIn(arm.value, environment)
+	}
+	panic(NewRuntimeErrorAt(expr.keyword, fmt.Sprintf("No match arm for %v%v%v.", YELLOW, stringifyValue(subject), RESET)))
+}
+
+// matchesPattern reports whether value matches the pattern of arm. A type
+// pattern names one of the builtinTypes; a class, which also matches
+// instances of its subclasses; or an enum, which matches its members.
+func (i *Interpreter) matchesPattern(arm *MatchArm, value interface{}) bool {
+	if arm.pattern != nil {
+		return i.isEqual(i.evaluate(arm.pattern), value)
+	}
+	if arm.typeName == nil {
+		return true
+	}
+	if builtinTypes[arm.typeName.lexeme] {
+		return loxTypeName(value) == arm.typeName.lexeme
+	}
+
+	switch kind := i.environment.get(arm.typeName).(type) {
+	case *LoxClass:
+		return isInstanceOf(value, kind)
+	case *LoxEnum:
+		member, ok := value.(*LoxEnumMember)
+		return ok && member.enum == kind
+	}
+	panic(NewRuntimeErrorAt(arm.typeName, fmt.Sprintf("%v'%v'%v is not a type, class or enum.", YELLOW, arm.typeName.lexeme, RESET)))
+}
+
+// isInstanceOf reports whether value is an instance of class or one of its
+// subclasses.
+func isInstanceOf(value interface{}, class *LoxClass) bool {
+	instance, ok := value.(*LoxInstance)
+	if !ok {
+		return false
+	}
+	for ancestor := instance.class; ancestor != nil; ancestor = ancestor.superclass {
+		if ancestor == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files used by 'jlox get'. A project lists its dependencies in lox.mod:
+//
+//	# Local library directories and remote scripts
+//	require ../shared/strings
+//	require https://example.com/lox/json.lox
+//
+// 'jlox get' copies every .lox file of each dependency into lox_modules/,
+// one directory per dependency named after its whole require source, and
+// records a checksum for each file in lox.lock. Later runs refuse to vendor
+// files whose checksum changed unless -update is given, so a checked-in
+// lox.lock makes builds reproducible.
+const (
+	moduleManifestFile = "lox.mod"
+	moduleLockFile     = "lox.lock"
+	moduleVendorDir    = "lox_modules"
+)
+
+// moduleFile is one vendored file of a dependency.
+type moduleFile struct {
+	source   string // The require line's source
+	path     string // Where the file is vendored, relative to the project
+	contents []byte
+}
+
+// checksum returns the file's lock entry checksum.
+func (f *moduleFile) checksum() string {
+	return "sha256:" + hashHex(f.contents)
+}
+
+// runGetCommand implements 'jlox get [-update]' in the current directory.
+func (lox *Lox) runGetCommand(args []string) {
+	getFlags := flag.NewFlagSet("get", flag.ExitOnError)
+	update := getFlags.Bool("update", false, "Accept changed dependencies and rewrite "+moduleLockFile+".")
+	getFlags.Parse(args)
+
+	requires, err := readModuleManifest(moduleManifestFile)
+	if err != nil {
+		log.Print(err)
+		lox.exit(EXIT_DATAERR)
+	}
+	locked, err := readModuleLock(moduleLockFile)
+	if err != nil {
+		log.Print(err)
+		lox.exit(EXIT_DATAERR)
+	}
+
+	var files []*moduleFile
+	for _, source := range requires {
+		fetched, err := fetchModule(source)
+		if err != nil {
+			log.Printf("Failed to get %v: %v", source, err)
+			lox.exit(EXIT_UNAVAILABLE)
+		}
+		files = append(files, fetched...)
+	}
+
+	for _, file := range files {
+		if checksum, ok := locked[file.path]; ok && checksum != file.checksum() && !*update {
+			log.Printf("Checksum mismatch for %v: %v has %v but %v was fetched. Run 'jlox get -update' to accept the change.", file.path, moduleLockFile, checksum, file.checksum())
+			lox.exit(EXIT_DATAERR)
+		}
+	}
+
+	if err := vendorModules(files); err != nil {
+		log.Print("Failed to vendor dependencies: ", err)
+		lox.exit(EXIT_CANTCREAT)
+	}
+	for _, file := range files {
+		fmt.Printf("%v %v\n", file.path, file.checksum())
+	}
+}
+
+// readModuleManifest returns the sources listed by the require lines of a
+// lox.mod file.
+func readModuleManifest(manifest string) ([]string, error) {
+	file, err := os.Open(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", manifest, err)
+	}
+	defer file.Close()
+
+	var requires []string
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 || fields[0] != "require" {
+			return nil, fmt.Errorf("%v:%v: expected 'require <directory or URL>'", manifest, line)
+		}
+		requires = append(requires, fields[1])
+	}
+	return requires, scanner.Err()
+}
+
+// readModuleLock returns the checksums in a lox.lock file by vendored path.
+// A missing lock file is the same as an empty one.
+func readModuleLock(lock string) (map[string]string, error) {
+	checksums := map[string]string{}
+	file, err := os.Open(lock)
+	if os.IsNotExist(err) {
+		return checksums, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %v: %v", lock, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%v:%v: expected '<source> <path> <checksum>'", lock, line)
+		}
+		checksums[fields[1]] = fields[2]
+	}
+	return checksums, scanner.Err()
+}
+
+// moduleClient downloads remote dependencies.
+var moduleClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchModule reads the .lox files of a dependency, from a directory or a
+// single-file HTTPS URL.
+func fetchModule(source string) ([]*moduleFile, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		if !strings.HasPrefix(source, "https://") {
+			return nil, fmt.Errorf("remote dependency %v must use HTTPS", source)
+		}
+		response, err := moduleClient.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("server returned %v", response.Status)
+		}
+		contents, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		_, address, _ := strings.Cut(source, "://")
+		name := path.Base(moduleAddress(address))
+		vendored := path.Join(moduleVendorDir, moduleDir(source), name)
+		return []*moduleFile{{source: source, path: vendored, contents: contents}}, nil
+	}
+
+	root := filepath.Clean(source)
+	var files []*moduleFile
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(file) != ".lox" {
+			return err
+		}
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		vendored := path.Join(moduleVendorDir, moduleDir(source), filepath.ToSlash(relative))
+		files = append(files, &moduleFile{source: source, path: vendored, contents: contents})
+		return nil
+	})
+	if err == nil && len(files) == 0 {
+		err = fmt.Errorf("no .lox files in %v", source)
+	}
+	return files, err
+}
+
+// moduleDir returns the directory under lox_modules/ that a dependency is
+// vendored into. It follows the whole source, so dependencies that only
+// share their last path element don't overwrite each other: a URL's host
+// and path without the '.lox', e.g. example.com/lox/json, or a directory's
+// path with '..' written as '@up' and '@root' for the root of an absolute
+// path, e.g. @up/shared/strings.
+func moduleDir(source string) string {
+	var elements []string
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		_, address, _ := strings.Cut(source, "://")
+		elements = strings.Split(strings.TrimSuffix(moduleAddress(address), ".lox"), "/")
+	} else {
+		directory := filepath.Clean(source)
+		directory = strings.TrimPrefix(directory, filepath.VolumeName(directory))
+		elements = strings.Split(filepath.ToSlash(directory), "/")
+		if filepath.IsAbs(source) {
+			elements[0] = "@root"
+		}
+	}
+
+	var dir []string
+	for _, element := range elements {
+		switch element {
+		case "", ".":
+		case "..":
+			dir = append(dir, "@up")
+		default:
+			dir = append(dir, element)
+		}
+	}
+	if len(dir) == 0 {
+		return "@here"
+	}
+	return path.Join(dir...)
+}
+
+// moduleAddress returns the host and path of a URL without its scheme,
+// dropping any query or fragment.
+func moduleAddress(address string) string {
+	address, _, _ = strings.Cut(address, "#")
+	address, _, _ = strings.Cut(address, "?")
+	return path.Clean(strings.TrimSuffix(address, "/"))
+}
+
+// vendorModules replaces lox_modules/ with the fetched files and rewrites
+// the lock file to match.
+func vendorModules(files []*moduleFile) error {
+	if err := os.RemoveAll(moduleVendorDir); err != nil {
+		return err
+	}
+	for _, file := range files {
+		vendored := filepath.FromSlash(file.path)
+		if err := os.MkdirAll(filepath.Dir(vendored), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(vendored, file.contents, 0o644); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(files, func(a, b int) bool { return files[a].path < files[b].path })
+	var lock strings.Builder
+	for _, file := range files {
+		fmt.Fprintf(&lock, "%v %v %v\n", file.source, file.path, file.checksum())
+	}
+	return os.WriteFile(moduleLockFile, []byte(lock.String()), 0o644)
+}
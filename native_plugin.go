@@ -0,0 +1,60 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// NativePluginSymbol is the function a native plugin must export. It is
+// called once when the plugin is imported, with a function that registers
+// each native:
+//
+//	func RegisterNatives(registerNative func(name string, arity int, fn func(arguments []interface{}) interface{})) {
+//		registerNative("double", 1, func(arguments []interface{}) interface{} {
+//			return arguments[0].(float64) * 2
+//		})
+//	}
+//
+// Plugins can't import this package, so the signature only uses builtin types.
+const NativePluginSymbol = "RegisterNatives"
+
+// RegisterNative defines a native function visible to every script the
+// interpreter runs, e.g. from a Go program embedding it. Values passed in
+// and returned are Lox values: float64, string, bool and nil, while
+// anything else is opaque to Go code unless converted with FromLox.
+// Richer Go values can be returned through ToLox. Returning a Go error
+// raises a runtime error with its message.
+func (i *Interpreter) RegisterNative(name string, arity int, fn func(arguments []interface{}) interface{}) {
+	i.natives.define(name, NewNativeFunction(name, arity, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		result := fn(arguments)
+		if err, ok := result.(error); ok {
+			panic(NewRuntimeError(LINE_UNKNOWN, err.Error()))
+		}
+		return result
+	}))
+}
+
+// VisitImportNativeStmt loads a Go plugin and registers its natives.
+func (i *Interpreter) VisitImportNativeStmt(stmt *ImportNativeStmt) interface{} {
+	path := stmt.path.literal.(string)
+	if i.sandbox {
+		panic(NewRuntimeErrorAt(stmt.path, "Native plugins are disabled in sandbox mode."))
+	}
+	library, err := plugin.Open(path)
+	if err != nil {
+		panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("Failed to load native plugin %v'%v'%v: %v", YELLOW, path, RESET, err)))
+	}
+
+	symbol, err := library.Lookup(NativePluginSymbol)
+	if err != nil {
+		panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("Native plugin %v'%v'%v doesn't export %v.", YELLOW, path, RESET, NativePluginSymbol)))
+	}
+	register, ok := symbol.(func(func(string, int, func([]interface{}) interface{})))
+	if !ok {
+		panic(NewRuntimeErrorAt(stmt.path, fmt.Sprintf("%v in native plugin %v'%v'%v has the wrong signature.", NativePluginSymbol, YELLOW, path, RESET)))
+	}
+
+	register(i.RegisterNative)
+	return nil
+}
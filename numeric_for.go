@@ -0,0 +1,147 @@
+// Package main implements a Lox language interpreter
+package main
+
+// numericFor describes a desugared counting loop of the shape
+// 'for (var i = start; i < limit; i = i + step) body'.
+type numericFor struct {
+	keyword     *Token      // The 'for' the loop was written with, for errors
+	declaration *VarStmt    // The counter declaration
+	condition   *BinaryExpr // Counter compared against the limit
+	body        Stmt        // The user's loop body
+	increment   *AssignExpr // The original increment expression
+	label       *Token      // The loop's label, if any
+	step        float64     // Signed amount added to the counter each iteration
+}
+
+// matchNumericFor reports whether a block is a desugared counting for loop.
+// The parser calls it for each for loop and keeps the result on the block.
+func matchNumericFor(block *BlockStmt) (*numericFor, bool) {
+	if len(block.statements) != 2 {
+		return nil, false
+	}
+	declaration, ok := block.statements[0].(*VarStmt)
+	if !ok || declaration.initializer == nil {
+		return nil, false
+	}
+	loop, ok := block.statements[1].(*WhileStmt)
+	if !ok {
+		return nil, false
+	}
+	name := declaration.name.lexeme
+
+	condition, ok := loop.condition.(*BinaryExpr)
+	if !ok || !isVariableNamed(condition.left, name) {
+		return nil, false
+	}
+	switch condition.operator.tokenType {
+	case LESS, LESS_EQUAL, GREATER, GREATER_EQUAL:
+	default:
+		return nil, false
+	}
+
+	assign, ok := loop.increment.(*AssignExpr)
+	if !ok || assign.name.lexeme != name {
+		return nil, false
+	}
+	update, ok := assign.value.(*BinaryExpr)
+	if !ok || !isVariableNamed(update.left, name) {
+		return nil, false
+	}
+	literal, ok := update.right.(*LiteralExpr)
+	if !ok {
+		return nil, false
+	}
+	step, ok := literal.value.(float64)
+	if !ok {
+		return nil, false
+	}
+	switch update.operator.tokenType {
+	case PLUS:
+	case MINUS:
+		step = -step
+	default:
+		return nil, false
+	}
+
+	return &numericFor{
+		keyword:     loop.keyword,
+		declaration: declaration,
+		condition:   condition,
+		body:        loop.body,
+		increment:   assign,
+		label:       loop.label,
+		step:        step,
+	}, true
+}
+
+// isVariableNamed reports whether expr reads the variable called name.
+func isVariableNamed(expr Expr, name string) bool {
+	variable, ok := expr.(*VariableExpr)
+	return ok && variable.name.lexeme == name
+}
+
+// executeNumericFor runs a counting loop without allocating an environment
+// per iteration. The counter lives in a single slot of the loop's environment
+// and is compared and stepped directly while it holds a number; anything
+// unusual (e.g. the body assigning a string to it) falls back to evaluating
+// the original condition and increment expressions.
+func (i *Interpreter) executeNumericFor(loop *numericFor) (result interface{}) {
+	environment := NewEnclosingEnvironment(i.environment)
+	previous := i.environment
+	defer func() {
+		i.environment = previous
+		if r := recover(); r != nil {
+			if breakError, ok := r.(*BreakError); !ok || !breakError.targets(loop.label) {
+				panic(r) // re-panic if it's not a break of this loop
+			}
+		}
+	}()
+
+	i.environment = environment
+	i.execute(loop.declaration)
+	name := loop.declaration.name.lexeme
+
+	for i.numericForCondition(loop, environment.values[name]) {
+		i.checkInterrupt(loop.keyword)
+		result = i.execute(loop.body)
+		switch signal := result.(type) {
+		case *ReturnError:
+			return result
+		case *ContinueError:
+			if !signal.targets(loop.label) {
+				return result // continue an outer loop
+			}
+			result = nil
+		}
+
+		if counter, ok := environment.values[name].(float64); ok {
+			environment.values[name] = counter + loop.step
+		} else {
+			i.evaluate(loop.increment)
+		}
+	}
+	return result
+}
+
+// numericForCondition evaluates the loop condition for the current counter.
+func (i *Interpreter) numericForCondition(loop *numericFor, counter interface{}) bool {
+	value, ok := counter.(float64)
+	if !ok {
+		return i.isTruthy(i.evaluate(loop.condition))
+	}
+	limit, ok := i.evaluate(loop.condition.right).(float64)
+	if !ok {
+		return i.isTruthy(i.evaluate(loop.condition))
+	}
+
+	switch loop.condition.operator.tokenType {
+	case LESS:
+		return value < limit
+	case LESS_EQUAL:
+		return value <= limit
+	case GREATER:
+		return value > limit
+	default:
+		return value >= limit
+	}
+}
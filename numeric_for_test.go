@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestPostMortemKeepsCountingLoopScope(t *testing.T) {
+	statements, ok := load("for (var i = 0; i < nil; i = i + 1) {}", nil)
+	if !ok {
+		t.Fatal("syntax errors")
+	}
+
+	_, err := NewInterpreter(WithPostMortem()).Interpret(statements)
+	runtimeError, ok := err.(*RuntimeError)
+	if !ok {
+		t.Fatalf("got error %v, want a runtime error", err)
+	}
+	if runtimeError.environment == nil || !runtimeError.environment.has("i") {
+		t.Error("the error's scope doesn't have the loop counter")
+	}
+}
@@ -0,0 +1,306 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Optimizer rewrites a parsed program at load time, before it runs.
+// It folds constant top-level variable initializers and diagnoses
+// global initializers that depend on each other in a cycle.
+type Optimizer struct {
+	evaluator *Interpreter // Computes folded values with the interpreter's own semantics, nil until needed
+	globals   *Environment // Globals the program will run with, nil for a fresh interpreter's
+	hadError  bool         // Whether a cyclic initialization was reported
+}
+
+// NewOptimizer creates a new Optimizer instance for a program that will run
+// in globals, e.g. the REPL's after earlier lines. nil stands for the
+// globals of a fresh interpreter.
+func NewOptimizer(globals *Environment) *Optimizer {
+	return &Optimizer{globals: globals}
+}
+
+// interpreter returns the evaluator, creating it the first time something
+// is folded. Many programs loaded, such as most REPL lines and plain
+// '@if NAME' conditions, have nothing to fold and never need one.
+func (o *Optimizer) interpreter() *Interpreter {
+	if o.evaluator == nil {
+		o.evaluator = NewInterpreter(WithCheckedIntegers())
+	}
+	return o.evaluator
+}
+
+// freshGlobals is the global environment of a new interpreter, which
+// already has the natives and the Error class.
+var freshGlobals = sync.OnceValue(func() *Environment {
+	return NewInterpreter().globals
+})
+
+// isPredefined reports whether name already has a value when the program
+// starts, so reading it before its declaration doesn't reach the
+// declaration.
+func (o *Optimizer) isPredefined(name string) bool {
+	if o.globals != nil {
+		return o.globals.has(name)
+	}
+	return freshGlobals().has(name)
+}
+
+// Optimize checks and rewrites the top-level statements of a program.
+func (o *Optimizer) Optimize(statements []Stmt) []Stmt {
+	o.checkGlobalCycles(statements)
+	if o.hadError {
+		return statements
+	}
+
+	for _, statement := range statements {
+		if varStmt, ok := statement.(*VarStmt); ok && varStmt.initializer != nil {
+			varStmt.initializer = o.fold(varStmt.initializer)
+		}
+	}
+	return statements
+}
+
+// fold replaces constant subexpressions with their literal value.
+// Expressions that would raise a runtime error are left untouched so the
+// error still happens, with the same message, when the program runs.
+func (o *Optimizer) fold(expr Expr) Expr {
+	switch e := expr.(type) {
+	case *GroupingExpr:
+		e.expression = o.fold(e.expression)
+		if literal, ok := e.expression.(*LiteralExpr); ok {
+			return literal
+		}
+	case *UnaryExpr:
+		e.right = o.fold(e.right)
+		if right, ok := e.right.(*LiteralExpr); ok && o.canFoldUnary(e.operator, right.value) {
+			if value, ok := o.evaluate(e); ok {
+				return &LiteralExpr{value: value}
+			}
+		}
+	case *BinaryExpr:
+		e.left = o.fold(e.left)
+		e.right = o.fold(e.right)
+		left, leftOk := e.left.(*LiteralExpr)
+		right, rightOk := e.right.(*LiteralExpr)
+		if leftOk && rightOk && o.canFoldBinary(e.operator, left.value, right.value) {
+			if value, ok := o.evaluate(e); ok {
+				return &LiteralExpr{value: value}
+			}
+		}
+	case *LogicalExpr:
+		e.left = o.fold(e.left)
+		e.right = o.fold(e.right)
+		if left, ok := e.left.(*LiteralExpr); ok {
+			if (e.operator.tokenType == OR) == o.interpreter().isTruthy(left.value) {
+				return left
+			}
+			return e.right
+		}
+	case *ConditionalExpr:
+		e.condition = o.fold(e.condition)
+		e.thenBranch = o.fold(e.thenBranch)
+		e.elseBranch = o.fold(e.elseBranch)
+		if condition, ok := e.condition.(*LiteralExpr); ok {
+			if o.interpreter().isTruthy(condition.value) {
+				return e.thenBranch
+			}
+			return e.elseBranch
+		}
+	}
+	return expr
+}
+
+// evaluate computes a constant expression with checked integers, so a
+// folded value is the same whether or not the program runs with them.
+// ok is false when the checks fail, leaving the error to happen at run
+// time if it's enabled.
+func (o *Optimizer) evaluate(expr Expr) (value interface{}, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isRuntimeError := r.(*RuntimeError); !isRuntimeError {
+				panic(r)
+			}
+			value, ok = nil, false
+		}
+	}()
+	return o.interpreter().evaluate(expr), true
+}
+
+// canFoldUnary reports whether a unary operator can be applied to value
+// without a runtime error.
+func (o *Optimizer) canFoldUnary(operator *Token, value interface{}) bool {
+	if operator.tokenType == MINUS || operator.tokenType == TILDE {
+		_, ok := value.(float64)
+		return ok
+	}
+	return true
+}
+
+// canFoldBinary reports whether a binary operator can be applied to the
+// operands without a runtime error.
+func (o *Optimizer) canFoldBinary(operator *Token, left, right interface{}) bool {
+	l, leftNumber := left.(float64)
+	r, rightNumber := right.(float64)
+	_, leftString := left.(string)
+	_, rightString := right.(string)
+
+	switch operator.tokenType {
+	case EQUAL_EQUAL, BANG_EQUAL:
+		return true
+	case PLUS:
+		return (leftNumber || leftString) && (rightNumber || rightString)
+	case SLASH:
+		return leftNumber && rightNumber && l != 0 && r != 0
+	case MINUS, STAR, STAR_STAR, GREATER, GREATER_EQUAL, LESS, LESS_EQUAL, AMPERSAND, PIPE, CARET:
+		return leftNumber && rightNumber
+	case LESS_LESS, GREATER_GREATER:
+		return leftNumber && rightNumber && toInteger(r) >= 0
+	}
+	return false
+}
+
+// checkGlobalCycles reports top-level variables whose initializers
+// depend on each other, e.g. 'var a = b; var b = a;'.
+func (o *Optimizer) checkGlobalCycles(statements []Stmt) {
+	var declarations []*VarStmt
+	for _, statement := range statements {
+		if varStmt, ok := statement.(*VarStmt); ok {
+			declarations = append(declarations, varStmt)
+		}
+	}
+
+	// A reference reads the closest earlier declaration of the name, or the
+	// first later one if the name hasn't been declared yet (a forward
+	// reference). A declaration's own initializer runs before the name is
+	// bound, and a name that's already a native or an existing global reads
+	// that until it's declared, so neither counts as a forward reference.
+	provider := func(index int, name string) int {
+		for earlier := index - 1; earlier >= 0; earlier-- {
+			if declarations[earlier].name.lexeme == name {
+				return earlier
+			}
+		}
+		for later := index + 1; later < len(declarations); later++ {
+			if declarations[later].name.lexeme == name {
+				if o.isPredefined(name) {
+					return -1
+				}
+				return later
+			}
+		}
+		return -1
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(declarations))
+	var path []int
+
+	var visit func(index int, reference *Token)
+	visit = func(index int, reference *Token) {
+		switch state[index] {
+		case done:
+			return
+		case visiting:
+			start := 0
+			for path[start] != index {
+				start++
+			}
+			var cycle []string
+			for _, step := range append(path[start:], index) {
+				cycle = append(cycle, declarations[step].name.lexeme)
+			}
+			name := declarations[index].name.lexeme
+			ReportError(reference.line, reference.column, "", fmt.Sprintf("Cyclic initialization of global %v'%v'%v (%v).", YELLOW, name, RESET, strings.Join(cycle, " -> ")))
+			o.hadError = true
+			return
+		}
+
+		state[index] = visiting
+		path = append(path, index)
+		if initializer := declarations[index].initializer; initializer != nil {
+			for _, dependency := range o.collectVariables(initializer, nil) {
+				if target := provider(index, dependency.lexeme); target != -1 {
+					visit(target, dependency)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[index] = done
+	}
+
+	for index := range declarations {
+		visit(index, nil)
+	}
+}
+
+// collectVariables appends the variables referenced by expr to names.
+func (o *Optimizer) collectVariables(expr Expr, names []*Token) []*Token {
+	switch e := expr.(type) {
+	case *VariableExpr:
+		names = append(names, e.name)
+	case *AssignExpr:
+		names = o.collectVariables(e.value, names)
+	case *BinaryExpr:
+		names = o.collectVariables(e.left, names)
+		names = o.collectVariables(e.right, names)
+	case *LogicalExpr:
+		names = o.collectVariables(e.left, names)
+		names = o.collectVariables(e.right, names)
+	case *ConditionalExpr:
+		names = o.collectVariables(e.condition, names)
+		names = o.collectVariables(e.thenBranch, names)
+		names = o.collectVariables(e.elseBranch, names)
+	case *UnaryExpr:
+		names = o.collectVariables(e.right, names)
+	case *GroupingExpr:
+		names = o.collectVariables(e.expression, names)
+	case *GetExpr:
+		names = o.collectVariables(e.object, names)
+	case *OptionalChainExpr:
+		names = o.collectVariables(e.expression, names)
+	case *MatchExpr:
+		names = o.collectVariables(e.subject, names)
+		for _, arm := range e.arms {
+			names = o.collectVariables(arm.value, names)
+		}
+	case *IndexExpr:
+		names = o.collectVariables(e.object, names)
+		names = o.collectVariables(e.index, names)
+	case *SliceExpr:
+		names = o.collectVariables(e.object, names)
+		names = o.collectVariables(e.start, names)
+		names = o.collectVariables(e.end, names)
+	case *IndexSetExpr:
+		names = o.collectVariables(e.object, names)
+		names = o.collectVariables(e.index, names)
+		names = o.collectVariables(e.value, names)
+	case *ListExpr:
+		for _, element := range e.elements {
+			names = o.collectVariables(element, names)
+		}
+	case *MapExpr:
+		for index, key := range e.keys {
+			names = o.collectVariables(key, names)
+			names = o.collectVariables(e.values[index], names)
+		}
+	case *SetExpr:
+		names = o.collectVariables(e.object, names)
+		names = o.collectVariables(e.value, names)
+	case *CallExpr:
+		names = o.collectVariables(e.callee, names)
+		for _, argument := range e.arguments {
+			names = o.collectVariables(argument, names)
+		}
+	case *DbgExpr:
+		names = o.collectVariables(e.call, names)
+	}
+	return names
+}
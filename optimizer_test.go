@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+// TestSelfReferenceIsNotACycle checks that a global whose initializer
+// reads its own name loads, whether the name is a native or a global
+// defined by an earlier REPL line.
+func TestSelfReferenceIsNotACycle(t *testing.T) {
+	lox := NewLox(false)
+	lox.run("var x = 1;")
+	for _, source := range []string{"var x = x + 1;", "var keys = keys({});"} {
+		if _, ok := load(source, lox); !ok {
+			t.Errorf("load(%q) reported an error", source)
+		}
+	}
+}
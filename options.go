@@ -0,0 +1,117 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// Option configures an Interpreter created by NewInterpreter. The command
+// line flags and programs embedding the interpreter both configure it
+// through options.
+type Option func(*Interpreter)
+
+// WithOutput sends the output of print statements to w instead of stdout.
+func WithOutput(w io.Writer) Option {
+	return func(i *Interpreter) {
+		if buffered, ok := w.(*bufio.Writer); ok {
+			i.out = buffered
+		} else {
+			i.out = bufio.NewWriter(w)
+		}
+	}
+}
+
+// WithBufferedOutput keeps printed output buffered until the caller flushes
+// it, instead of flushing after every print.
+func WithBufferedOutput() Option {
+	return func(i *Interpreter) { i.flushPrints = false }
+}
+
+// WithConstants defines a global constant for each entry of constants, as
+// -D does on the command line. They are defined again when the globals are
+// reset.
+func WithConstants(constants Defines) Option {
+	return func(i *Interpreter) {
+		i.constants = constants
+		i.defineConstants()
+	}
+}
+
+// WithRelease skips requires/ensures contract checks.
+func WithRelease() Option {
+	return func(i *Interpreter) { i.release = true }
+}
+
+// WithIterative evaluates expressions with an explicit stack instead of Go
+// recursion.
+func WithIterative() Option {
+	return func(i *Interpreter) { i.iterative = true }
+}
+
+// WithForbidNativeShadowing makes defining a global that shadows a native
+// an error instead of a warning.
+func WithForbidNativeShadowing() Option {
+	return func(i *Interpreter) { i.forbidNativeShadowing = true }
+}
+
+// WithOptionalArguments lets calls to Lox functions and classes pass
+// fewer arguments than declared, padded with nil, or more, which are
+// dropped.
+func WithOptionalArguments() Option {
+	return func(i *Interpreter) { i.optionalArguments = true }
+}
+
+// WithCheckedIntegers makes the bitwise operators stop with a runtime
+// error instead of truncating an operand that isn't a whole number, and
+// makes integer arithmetic stop instead of losing precision once a result
+// no longer fits exactly in a number.
+func WithCheckedIntegers() Option {
+	return func(i *Interpreter) { i.checkedIntegers = true }
+}
+
+// WithPostMortem records the innermost scope a runtime error was raised
+// in, so it can be inspected after the script stops. It slows down blocks
+// and calls a little.
+func WithPostMortem() Option {
+	return func(i *Interpreter) { i.postMortem = true }
+}
+
+// WithDialect makes imported scripts use the keyword spellings of dialect,
+// like the script that imports them.
+func WithDialect(dialect *Dialect) Option {
+	return func(i *Interpreter) { i.dialect = dialect }
+}
+
+// WithBundledImports makes imports of the given paths use the scripts
+// carried by a bundle instead of reading files.
+func WithBundledImports(scripts map[string][]byte) Option {
+	return func(i *Interpreter) { i.bundled = scripts }
+}
+
+// WithSandbox disallows remote imports and native plugins.
+func WithSandbox() Option {
+	return func(i *Interpreter) { i.sandbox = true }
+}
+
+// WithTimeLimit stops each call to Interpret with a runtime error once it
+// has run for limit. A limit of 0 means no limit.
+func WithTimeLimit(limit time.Duration) Option {
+	return func(i *Interpreter) { i.timeLimit = limit }
+}
+
+// WithMaxCallDepth raises a "Stack overflow." runtime error when calls
+// nest more than depth deep. A depth of 0 means no limit, leaving runaway
+// recursion to exhaust Go's stack. The default is defaultMaxCallDepth,
+// which needs Go's stack limit raised to maxGoStack with
+// debug.SetMaxStack to be reached without crashing.
+func WithMaxCallDepth(depth int) Option {
+	return func(i *Interpreter) { i.maxCallDepth = depth }
+}
+
+// WithCallCounts tallies every call into counts, keyed by function name.
+// Interpreters running concurrently need a map each.
+func WithCallCounts(counts map[string]int) Option {
+	return func(i *Interpreter) { i.callCounts = counts }
+}
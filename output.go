@@ -0,0 +1,43 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// stdout buffers everything the interpreter prints.
+// It is flushed after every run, before any error is logged, and after
+// every print when output isn't buffered.
+var stdout = bufio.NewWriter(os.Stdout)
+
+// outputLock serializes writes to the outputs of interpreters, which may be
+// shared: every interpreter prints to stdout unless given another writer,
+// and forks of a snapshot share their base's output.
+var outputLock sync.Mutex
+
+// flushStdout writes any buffered output to the real stdout.
+func flushStdout() {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	stdout.Flush()
+}
+
+// flushingWriter flushes stdout before each write, so buffered program
+// output always appears before an error message.
+type flushingWriter struct {
+	writer io.Writer
+}
+
+func (f flushingWriter) Write(p []byte) (int, error) {
+	flushStdout()
+	return f.writer.Write(p)
+}
+
+// isInteractive reports whether file is connected to a terminal.
+func isInteractive(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,150 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// precedence is how tightly an operator binds, loosest first.
+type precedence int
+
+const (
+	PREC_NONE precedence = iota
+	PREC_ASSIGNMENT
+	PREC_CONDITIONAL
+	PREC_OR
+	PREC_AND
+	PREC_EQUALITY
+	PREC_BITWISE
+	PREC_COMPARISON
+	PREC_TERM
+	PREC_FACTOR
+	PREC_UNARY
+	PREC_EXPONENT
+	PREC_CALL
+)
+
+// toString returns the name of a precedence level.
+func (p precedence) toString() string {
+	switch p {
+	case PREC_ASSIGNMENT:
+		return "assignment"
+	case PREC_CONDITIONAL:
+		return "conditional"
+	case PREC_OR:
+		return "or"
+	case PREC_AND:
+		return "and"
+	case PREC_EQUALITY:
+		return "equality"
+	case PREC_BITWISE:
+		return "bitwise"
+	case PREC_COMPARISON:
+		return "comparison"
+	case PREC_TERM:
+		return "term"
+	case PREC_FACTOR:
+		return "factor"
+	case PREC_UNARY:
+		return "unary"
+	case PREC_EXPONENT:
+		return "exponent"
+	case PREC_CALL:
+		return "call"
+	default:
+		return "none"
+	}
+}
+
+// parseRule says how a token is parsed inside an expression. prefix parses
+// an expression that starts with the token; infix parses one where the
+// token follows a complete left operand, at the given precedence.
+// Tokens without a prefix rule start a primary expression.
+type parseRule struct {
+	symbol           string // How the operator is written, for documentation
+	prefix           func(p *Parser, operator *Token) Expr
+	infix            func(p *Parser, left Expr, operator *Token) Expr
+	precedence       precedence // Precedence of the infix form
+	rightAssociative bool       // Whether the infix form groups to the right
+	unary            bool       // Whether the prefix form is a unary operator rather than a literal
+}
+
+// parseRules is the expression grammar's operator table, indexed by token
+// type. Adding an operator means adding its token and a row here.
+var parseRules [EOF + 1]parseRule
+
+func init() {
+	binary := func(symbol string, level precedence) parseRule {
+		return parseRule{symbol: symbol, infix: (*Parser).binary, precedence: level}
+	}
+
+	parseRules[EQUAL] = parseRule{symbol: "=", infix: (*Parser).assignment, precedence: PREC_ASSIGNMENT, rightAssociative: true}
+	parseRules[QUESTION] = parseRule{symbol: "?:", infix: (*Parser).conditional, precedence: PREC_CONDITIONAL, rightAssociative: true}
+	parseRules[OR] = parseRule{symbol: "or", infix: (*Parser).logical, precedence: PREC_OR}
+	parseRules[AND] = parseRule{symbol: "and", infix: (*Parser).logical, precedence: PREC_AND}
+	parseRules[BANG_EQUAL] = binary("!=", PREC_EQUALITY)
+	parseRules[EQUAL_EQUAL] = binary("==", PREC_EQUALITY)
+	parseRules[AMPERSAND] = binary("&", PREC_BITWISE)
+	parseRules[PIPE] = binary("|", PREC_BITWISE)
+	parseRules[CARET] = binary("^", PREC_BITWISE)
+	parseRules[LESS_LESS] = binary("<<", PREC_BITWISE)
+	parseRules[GREATER_GREATER] = binary(">>", PREC_BITWISE)
+	parseRules[GREATER] = binary(">", PREC_COMPARISON)
+	parseRules[GREATER_EQUAL] = binary(">=", PREC_COMPARISON)
+	parseRules[LESS] = binary("<", PREC_COMPARISON)
+	parseRules[LESS_EQUAL] = binary("<=", PREC_COMPARISON)
+	parseRules[PLUS] = binary("+", PREC_TERM)
+	parseRules[MINUS] = parseRule{symbol: "-", prefix: (*Parser).unary, unary: true, infix: (*Parser).binary, precedence: PREC_TERM}
+	parseRules[SLASH] = binary("/", PREC_FACTOR)
+	parseRules[STAR] = binary("*", PREC_FACTOR)
+	parseRules[BANG] = parseRule{symbol: "!", prefix: (*Parser).unary, unary: true}
+	parseRules[TILDE] = parseRule{symbol: "~", prefix: (*Parser).unary, unary: true}
+	parseRules[STAR_STAR] = parseRule{symbol: "**", infix: (*Parser).binary, precedence: PREC_EXPONENT, rightAssociative: true}
+	parseRules[LEFT_PAREN] = parseRule{symbol: "()", infix: (*Parser).finishCall, precedence: PREC_CALL}
+	parseRules[DOT] = parseRule{symbol: ".", infix: (*Parser).property, precedence: PREC_CALL}
+	parseRules[QUESTION_DOT] = parseRule{symbol: "?.", infix: (*Parser).optionalProperty, precedence: PREC_CALL}
+	parseRules[LEFT_BRACKET] = parseRule{symbol: "[]", prefix: (*Parser).list, infix: (*Parser).index, precedence: PREC_CALL}
+	parseRules[LEFT_BRACE] = parseRule{symbol: "{}", prefix: (*Parser).mapLiteral}
+	parseRules[FUN] = parseRule{symbol: "fun", prefix: (*Parser).lambda}
+}
+
+// writeOperatorTable writes the operator table as Markdown, tightest
+// binding first, for 'jlox operators'.
+func writeOperatorTable(w io.Writer) {
+	fmt.Fprintln(w, "| Precedence | Operators | Associativity |")
+	fmt.Fprintln(w, "| --- | --- | --- |")
+
+	var prefixes []string
+	for _, rule := range parseRules {
+		if rule.unary {
+			prefixes = append(prefixes, markdownCode(rule.symbol))
+		}
+	}
+	for level := PREC_CALL; level > PREC_NONE; level-- {
+		if level == PREC_UNARY {
+			fmt.Fprintf(w, "| %v | %v | right |\n", level.toString(), strings.Join(prefixes, " "))
+			continue
+		}
+
+		var operators []string
+		associativity := "left"
+		for _, rule := range parseRules {
+			if rule.infix != nil && rule.precedence == level {
+				operators = append(operators, markdownCode(rule.symbol))
+				if rule.rightAssociative {
+					associativity = "right"
+				}
+			}
+		}
+		if len(operators) > 0 {
+			fmt.Fprintf(w, "| %v | %v | %v |\n", level.toString(), strings.Join(operators, " "), associativity)
+		}
+	}
+}
+
+// markdownCode formats an operator as inline code inside a table cell.
+func markdownCode(symbol string) string {
+	return "`" + strings.ReplaceAll(symbol, "|", `\|`) + "`"
+}
@@ -3,7 +3,6 @@ package main
 
 import (
 	"fmt"
-	"log"
 )
 
 // Parser implements a recursive descent parser for the Lox language.
@@ -11,15 +10,47 @@ import (
 type Parser struct {
 	tokens  []*Token // List of tokens to parse
 	current int      // Current position in the token list
-	loopDepth int    // Track nested loop depth
+	loops []enclosingLoop // Enclosing loops, innermost last
+	currentClass classType // Kind of class declaration being parsed
+	currentFunction functionType // Kind of function body being parsed
+	hadError bool // Whether a syntax error was reported
+
+	// Per-parse arenas for the most common AST nodes.
+	binaryExprs   arena[BinaryExpr]
+	literalExprs  arena[LiteralExpr]
+	variableExprs arena[VariableExpr]
+	logicalExprs  arena[LogicalExpr]
+	unaryExprs    arena[UnaryExpr]
 }
 
+// parseError unwinds the parser back to the enclosing declaration after a
+// syntax error has been reported, so it can synchronize and keep parsing.
+type parseError struct{}
+
+// classType identifies the kind of class enclosing the code being parsed.
+type classType int
+
+const (
+	CLASS_NONE classType = iota
+	CLASS_CLASS
+	CLASS_SUBCLASS
+)
+
+// functionType identifies the kind of function enclosing the code being parsed.
+type functionType int
+
+const (
+	FUNCTION_NONE functionType = iota
+	FUNCTION_FUNCTION
+	FUNCTION_METHOD
+	FUNCTION_INITIALIZER
+)
+
 // NewParser creates a new Parser instance with the given tokens.
 func NewParser(tokens []*Token) *Parser {
 	return &Parser{
 		tokens:  tokens,
 		current: 0,
-		loopDepth: 0,
 	}
 }
 
@@ -28,7 +59,9 @@ func NewParser(tokens []*Token) *Parser {
 func (p *Parser) Parse() []Stmt {
 	var statements []Stmt
 	for !p.isAtEnd() {
-		statements = append(statements, p.declaration())
+		if statement := p.declaration(); statement != nil {
+			statements = append(statements, statement)
+		}
 	}
 
 	return statements
@@ -37,24 +70,106 @@ func (p *Parser) Parse() []Stmt {
 // expression parses an expression.
 // Handles the lowest precedence level of expressions.
 func (p *Parser) expression() Expr {
-	return p.assignment()
+	return p.parsePrecedence(PREC_ASSIGNMENT)
 }
 
 // declaration parses a declaration statement (var, function, etc.).
-func (p *Parser) declaration() Stmt {
-	if p.match(FUN) {
+// After a syntax error it skips to the next statement and returns nil.
+func (p *Parser) declaration() (statement Stmt) {
+	loops, currentClass, currentFunction := p.loops, p.currentClass, p.currentFunction
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(parseError); !ok {
+				panic(r) // re-panic if it's not a syntax error
+			}
+			p.loops, p.currentClass, p.currentFunction = loops, currentClass, currentFunction
+			p.synchronize()
+			statement = nil
+		}
+	}()
+
+	if p.match(CLASS) {
+		return p.classDeclaration()
+	}
+	if p.match(ENUM) {
+		return p.enumDeclaration()
+	}
+	if p.check(FUN) && !p.checkNext(LEFT_PAREN) {
+		p.advance()
 		return p.function("function")
 	}
 	if p.match(VAR) {
 		return p.varDeclaration()
 	}
+	if p.match(CONST) {
+		return p.constDeclaration()
+	}
 	return p.statement()
 }
 
+// enumDeclaration parses 'enum Name { MEMBER, ... }'. A trailing comma
+// after the last member is allowed.
+func (p *Parser) enumDeclaration() Stmt {
+	name := p.consume(IDENTIFIER, "Expect enum name.")
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v before enum members.", YELLOW, RESET))
+	var members []*Token
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		members = p.appendUniqueName(members, p.consume(IDENTIFIER, "Expect enum member name."), "enum member")
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACE, fmt.Sprintf("Expect %v'}'%v after enum members.", YELLOW, RESET))
+	return &EnumStmt{name: name, members: members}
+}
+
+// classDeclaration parses a class declaration and its methods.
+func (p *Parser) classDeclaration() Stmt {
+	name := p.consume(IDENTIFIER, "Expect class name.")
+
+	enclosingClass := p.currentClass
+	p.currentClass = CLASS_CLASS
+	defer func() { p.currentClass = enclosingClass }()
+
+	var superclass *VariableExpr
+	if p.match(LESS) {
+		superclassName := p.consume(IDENTIFIER, "Expect superclass name.")
+		if superclassName.lexeme == name.lexeme {
+			p.error(superclassName, "A class can't inherit from itself.")
+		}
+		superclass = &VariableExpr{name: superclassName}
+		p.currentClass = CLASS_SUBCLASS
+	}
+
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v before class body.", YELLOW, RESET))
+
+	var methods []*FunctionStmt
+	var staticMethods []*FunctionStmt
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		if p.match(STATIC) {
+			staticMethods = append(staticMethods, p.function("static method").(*FunctionStmt))
+		} else {
+			methods = append(methods, p.function("method").(*FunctionStmt))
+		}
+	}
+
+	p.consume(RIGHT_BRACE, fmt.Sprintf("Expect %v'}'%v after class body.", YELLOW, RESET))
+	return &ClassStmt{
+		name:          name,
+		superclass:    superclass,
+		methods:       methods,
+		staticMethods: staticMethods,
+	}
+}
+
 // statement parses a statement (expression, print, block, etc.).
 func (p *Parser) statement() Stmt {
+	if p.check(IDENTIFIER) && p.checkNext(COLON) {
+		return p.labeledStatement()
+	}
+
 	if p.match(FOR) {
-		return p.forStatement()
+		return p.forStatement(nil)
 	}
 
 	if p.match(IF) {
@@ -70,31 +185,183 @@ func (p *Parser) statement() Stmt {
 	}
 
 	if p.match(WHILE) { 
-		return p.whileStatement()
+		return p.whileStatement(nil)
+	}
+
+	if p.match(USING) {
+		return p.usingStatement()
+	}
+
+	if p.match(IMPORT) {
+		return p.importStatement()
+	}
+
+	if p.match(TRY) {
+		return p.tryStatement()
+	}
+
+	if p.match(THROW) {
+		keyword := p.previous()
+		value := p.expression()
+		p.consume(SEMICOLON, fmt.Sprintf("Expected %v';'%v after thrown value.", YELLOW, RESET))
+		return &ThrowStmt{keyword: keyword, value: value}
 	}
 
 	if p.match(BREAK) {
-		if p.loopDepth == 0 {
-			log.Fatal(ReportExit(p.previous().line, "", "Cannot use 'break' outside of a loop."))
+		keyword := p.previous()
+		if len(p.loops) == 0 {
+			p.error(keyword, "Cannot use 'break' outside of a loop.")
+		}
+		// An identifier naming an enclosing loop is a label; anything else
+		// is the value of a loop expression.
+		var label *Token
+		if p.check(IDENTIFIER) && p.isLoopLabel(p.peek().lexeme) {
+			label = p.advance()
+		}
+		var value Expr
+		if !p.check(SEMICOLON) {
+			if target := p.targetLoop(label); target != nil && !target.expression {
+				p.error(p.peek(), "Only a 'loop' expression can break with a value.")
+			}
+			value = p.expression()
 		}
 		p.consume(SEMICOLON, fmt.Sprintf("Expected %v';'%v after 'break'.", YELLOW, RESET))
-		return &BreakStmt{}
+		return &BreakStmt{keyword: keyword, label: label, value: value}
 	}
 
-	if p.match(LEFT_BRACE) {
-		return &BlockStmt{
-			statements: p.block(),
+	if p.match(CONTINUE) {
+		keyword := p.previous()
+		if len(p.loops) == 0 {
+			p.error(keyword, "Cannot use 'continue' outside of a loop.")
+		}
+		var label *Token
+		if p.match(IDENTIFIER) {
+			label = p.previous()
+			if !p.isLoopLabel(label.lexeme) {
+				p.error(label, fmt.Sprintf("No enclosing loop is labeled %v'%v'%v.", YELLOW, label.lexeme, RESET))
+			} else if p.crossesLoopExpression(label.lexeme) {
+				p.error(label, fmt.Sprintf("Can't continue %v'%v'%v from inside a 'loop' expression.", YELLOW, label.lexeme, RESET))
+			}
 		}
+		p.consume(SEMICOLON, fmt.Sprintf("Expected %v';'%v after 'continue'.", YELLOW, RESET))
+		return &ContinueStmt{keyword: keyword, label: label}
+	}
+
+	// A loop in statement position doesn't need a trailing ';'.
+	if p.match(LOOP) {
+		return &ExpressionStmt{expression: p.loopExpression(nil, false)}
+	}
+
+	if p.match(LEFT_BRACE) {
+		return p.newBlock(p.block())
 	}
 
 	return p.expressionStatement()
 }
 
-func (p *Parser) forStatement() Stmt {
+// labeledStatement parses a loop with a label, e.g. 'outer: while (...) {}',
+// which lets 'break outer;' and 'continue outer;' reach it from nested loops.
+func (p *Parser) labeledStatement() Stmt {
+	label := p.advance()
+	p.advance() // The ':'
+	if p.isLoopLabel(label.lexeme) {
+		p.error(label, fmt.Sprintf("Label %v'%v'%v is already used by an enclosing loop.", YELLOW, label.lexeme, RESET))
+	}
+
+	switch {
+	case p.match(WHILE):
+		return p.whileStatement(label)
+	case p.match(FOR):
+		return p.forStatement(label)
+	case p.match(LOOP):
+		return &ExpressionStmt{expression: p.loopExpression(label, false)}
+	}
+	p.error(p.peek(), fmt.Sprintf("Expect a loop after label %v'%v'%v.", YELLOW, label.lexeme, RESET))
+	panic(parseError{})
+}
+
+// enclosingLoop describes a loop the parser is inside of.
+type enclosingLoop struct {
+	label      string // Empty for an unlabeled loop
+	expression bool   // Whether it's a 'loop' expression whose value is used
+}
+
+// enterLoop records that the parser is inside a loop, which may be labeled.
+func (p *Parser) enterLoop(label *Token, expression bool) {
+	loop := enclosingLoop{expression: expression}
+	if label != nil {
+		loop.label = label.lexeme
+	}
+	p.loops = append(p.loops, loop)
+}
+
+// exitLoop undoes the matching enterLoop.
+func (p *Parser) exitLoop() {
+	p.loops = p.loops[:len(p.loops)-1]
+}
+
+// isLoopLabel reports whether name labels one of the enclosing loops.
+func (p *Parser) isLoopLabel(name string) bool {
+	for _, loop := range p.loops {
+		if loop.label == name {
+			return true
+		}
+	}
+	return false
+}
+
+// targetLoop returns the loop a 'break' or 'continue' with the given
+// label reaches: the labeled loop, or the innermost one without a label.
+func (p *Parser) targetLoop(label *Token) *enclosingLoop {
+	for i := len(p.loops) - 1; i >= 0; i-- {
+		if label == nil || p.loops[i].label == label.lexeme {
+			return &p.loops[i]
+		}
+	}
+	return nil
+}
+
+// crossesLoopExpression reports whether a 'loop' expression sits between
+// the current position and the loop labeled name. Such an expression
+// can't hand a 'continue' on to the loops around it.
+func (p *Parser) crossesLoopExpression(name string) bool {
+	for i := len(p.loops) - 1; i >= 0 && p.loops[i].label != name; i-- {
+		if p.loops[i].expression {
+			return true
+		}
+	}
+	return false
+}
+
+// inLoopExpression reports whether the parser is inside the body of a
+// 'loop' expression in the current function whose value is used.
+func (p *Parser) inLoopExpression() bool {
+	for _, loop := range p.loops {
+		if loop.expression {
+			return true
+		}
+	}
+	return false
+}
+
+// forStatement parses a for loop and desugars it into a while loop.
+// The synthesized while loop keeps the 'for' token as its keyword so
+// anything reported about the loop points at the user's source.
+func (p *Parser) forStatement(label *Token) Stmt {
+	keyword := p.previous()
 	p.consume(LEFT_PAREN, fmt.Sprintf("Expected %v'('%v after 'for'.", YELLOW, RESET))
 
-	p.loopDepth++
-	defer func() { p.loopDepth-- }()
+	p.enterLoop(label, false)
+	defer p.exitLoop()
+
+	if p.checkForIn() {
+		p.advance()
+		name := p.advance()
+		p.advance()
+		iterable := p.expression()
+		p.consume(RIGHT_PAREN, fmt.Sprintf("Expected %v')'%v after for-in clause.", YELLOW, RESET))
+		return &ForInStmt{keyword: keyword, name: name, iterable: iterable, body: p.statement(), label: label}
+	}
 
 	var initializer Stmt
 	if p.match(SEMICOLON) {
@@ -119,29 +386,134 @@ func (p *Parser) forStatement() Stmt {
 
 	body := p.statement()
 
-	if increment != nil {
-		body = &BlockStmt{
-			statements: []Stmt{
-				body,
-				&ExpressionStmt{expression: increment},
-			},
-		}
-	}
-
+	// The increment is kept on the while loop rather than appended to the
+	// body, so that 'continue' still runs it.
 	if condition == nil {
-		condition = &LiteralExpr{value: true}
+		condition = p.literalExprs.alloc(LiteralExpr{value: true})
 	}
-	body = &WhileStmt{condition: condition, body: body}
+	body = &WhileStmt{keyword: keyword, condition: condition, body: body, increment: increment, label: label}
 
 	if initializer != nil {
-		body = &BlockStmt{
-			statements: []Stmt{initializer, body},
-		}
+		block := p.newBlock([]Stmt{initializer, body})
+		// Counting loops are recognized once here rather than on every run.
+		block.numericFor, _ = matchNumericFor(block)
+		body = block
 	}
 
 	return body
 }
 
+// checkForIn reports whether the for loop being parsed is a for-in loop,
+// 'for (var name in iterable)'. 'in' is only special there.
+func (p *Parser) checkForIn() bool {
+	if !p.check(VAR) || p.current+2 >= len(p.tokens) {
+		return false
+	}
+	name, in := p.tokens[p.current+1], p.tokens[p.current+2]
+	return name.tokenType == IDENTIFIER && in.tokenType == IDENTIFIER && in.lexeme == "in"
+}
+
+// importStatement parses 'import "path";', which runs another script, and
+// 'import native "path";', which loads a Go plugin that defines extra
+// natives. 'native' is only special after 'import'.
+func (p *Parser) importStatement() Stmt {
+	keyword := p.previous()
+	if p.check(IDENTIFIER) && p.peek().lexeme == "native" {
+		p.advance()
+		path := p.consume(STRING, "Expect plugin path.")
+		p.consume(SEMICOLON, fmt.Sprintf("Expect %v';'%v after import.", YELLOW, RESET))
+		return &ImportNativeStmt{keyword: keyword, path: path}
+	}
+
+	path := p.consume(STRING, "Expect script path or URL after 'import'.")
+	p.consume(SEMICOLON, fmt.Sprintf("Expect %v';'%v after import.", YELLOW, RESET))
+	return &ImportStmt{keyword: keyword, path: path}
+}
+
+// matchExpression parses 'match (subject) { pattern -> value, ... }'.
+// A trailing comma after the last arm is allowed.
+func (p *Parser) matchExpression() Expr {
+	keyword := p.previous()
+	p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after %v'match'%v.", YELLOW, RESET, YELLOW, RESET))
+	subject := p.expression()
+	p.consume(RIGHT_PAREN, fmt.Sprintf("Expect %v')'%v after match subject.", YELLOW, RESET))
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v before match arms.", YELLOW, RESET))
+
+	var arms []*MatchArm
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		arms = append(arms, p.matchArm())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACE, fmt.Sprintf("Expect %v'}'%v after match arms.", YELLOW, RESET))
+	if len(arms) == 0 {
+		p.error(keyword, "A match needs at least one arm.")
+	}
+	return &MatchExpr{keyword: keyword, subject: subject, arms: arms}
+}
+
+// matchArm parses one 'pattern -> value' arm of a match. A pattern is a
+// literal such as 1, -2, "text", true or nil; an enum member such as
+// 'Color.RED'; a type name followed by a variable, as in 'number n' or
+// 'Point p'; or a variable alone, which matches anything. '_' stands for a
+// variable that isn't bound.
+func (p *Parser) matchArm() *MatchArm {
+	arm := &MatchArm{}
+	switch {
+	case p.match(NUMBER, STRING):
+		arm.pattern = &LiteralExpr{value: p.previous().literal}
+	case p.match(TRUE):
+		arm.pattern = &LiteralExpr{value: true}
+	case p.match(FALSE):
+		arm.pattern = &LiteralExpr{value: false}
+	case p.match(NIL):
+		arm.pattern = &LiteralExpr{value: nil}
+	case p.check(MINUS) && p.checkNext(NUMBER):
+		p.advance()
+		arm.pattern = &LiteralExpr{value: -p.advance().literal.(float64)}
+	case p.check(IDENTIFIER) && p.checkNext(DOT):
+		enum := p.variableExprs.alloc(VariableExpr{p.advance()})
+		p.advance()
+		arm.pattern = &GetExpr{object: enum, name: p.consume(IDENTIFIER, fmt.Sprintf("Expect member name after %v'.'%v.", YELLOW, RESET))}
+	case p.check(IDENTIFIER) && p.checkNext(IDENTIFIER):
+		arm.typeName = p.advance()
+		arm.binding = p.advance()
+	case p.match(IDENTIFIER):
+		arm.binding = p.previous()
+	default:
+		p.error(p.peek(), "Expect a pattern.")
+		panic(parseError{})
+	}
+	if arm.binding != nil && arm.binding.lexeme == "_" {
+		arm.binding = nil
+	}
+
+	p.consume(ARROW, fmt.Sprintf("Expect %v'->'%v after pattern.", YELLOW, RESET))
+	arm.value = p.expression()
+	return arm
+}
+
+// loopExpression parses the body of a 'loop' expression.
+// The loop runs until a 'break' and evaluates to the break's value.
+// valued is false for a loop in statement position, whose value is thrown
+// away: like any other loop statement, a 'return' or an outer 'continue'
+// passes through it, but a 'break' can't carry a value.
+func (p *Parser) loopExpression(label *Token, valued bool) Expr {
+	keyword := p.previous()
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v after 'loop'.", YELLOW, RESET))
+
+	p.enterLoop(label, valued)
+	body := p.newBlock(p.block())
+	p.exitLoop()
+
+	return &LoopExpr{
+		keyword: keyword,
+		body:    body,
+		label:   label,
+	}
+}
+
 // ifStatement parses an if statement.
 func (p *Parser) ifStatement() Stmt {
 	p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after %v'if'%v.", YELLOW, RESET, YELLOW, RESET))
@@ -172,9 +544,24 @@ func (p *Parser) printStatement() Stmt {
 
 func (p *Parser) returnStatement() Stmt {
 	keyword := p.previous()
+	if p.inLoopExpression() {
+		// The loop's value would swallow the return; use 'break' instead.
+		p.error(keyword, "Can't return from inside a 'loop' expression.")
+	}
 	var value Expr
 	if !p.check(SEMICOLON) {
+		if p.currentFunction == FUNCTION_INITIALIZER {
+			p.error(keyword, "Can't return a value from an initializer.")
+		}
 		value = p.expression()
+		if p.check(COMMA) {
+			// 'return a, b;' returns the values as a list.
+			elements := []Expr{value}
+			for p.match(COMMA) {
+				elements = append(elements, p.expression())
+			}
+			value = &ListExpr{bracket: keyword, elements: elements}
+		}
 	}
 
 	p.consume(SEMICOLON, fmt.Sprintf("Expect %v';'%v after return value.", YELLOW, RESET))
@@ -186,7 +573,13 @@ func (p *Parser) returnStatement() Stmt {
 
 // varDeclaration parses a variable declaration statement.
 func (p *Parser) varDeclaration() Stmt {
+	if p.match(LEFT_BRACKET, LEFT_BRACE) {
+		return p.patternDeclaration(p.previous())
+	}
 	name := p.consume(IDENTIFIER, "Expect variable name.")
+	if p.check(COMMA) {
+		return p.destructuringDeclaration(name)
+	}
 
 	var initializer Expr
 	if p.match(EQUAL) {
@@ -200,18 +593,122 @@ func (p *Parser) varDeclaration() Stmt {
 	}
 }
 
-func (p *Parser) whileStatement() Stmt {
+// constDeclaration parses 'const name = initializer;', a variable that
+// can't be assigned to. Constants whose value is known at compile time are
+// substituted by the preprocessor, so only the others get here.
+func (p *Parser) constDeclaration() Stmt {
+	name := p.consume(IDENTIFIER, "Expect constant name.")
+	p.consume(EQUAL, fmt.Sprintf("Expect %v'='%v after constant name.", YELLOW, RESET))
+	initializer := p.expression()
+	p.consume(SEMICOLON, fmt.Sprintf("Expect %v';'%v after constant value.", YELLOW, RESET))
+	return &VarStmt{
+		name:        name,
+		initializer: initializer,
+		constant:    true,
+	}
+}
+
+// destructuringDeclaration parses 'var a, b = list;', after the first name,
+// which declares a variable for each element of a list. Without an
+// initializer every variable starts as nil.
+func (p *Parser) destructuringDeclaration(first *Token) Stmt {
+	names := []*Token{first}
+	for p.match(COMMA) {
+		names = p.appendUniqueName(names, p.consume(IDENTIFIER, "Expect variable name."), "variable")
+	}
+
+	var equals *Token
+	var initializer Expr
+	if p.match(EQUAL) {
+		equals = p.previous()
+		initializer = p.expression()
+	}
+
+	p.consume(SEMICOLON, fmt.Sprintf("Expected %v';'%v after variable declaration.", YELLOW, RESET))
+	return &DestructureStmt{names: names, equals: equals, initializer: initializer}
+}
+
+// patternDeclaration parses 'var [a, b] = list;', which unpacks a list like
+// 'var a, b = list;', and 'var {x, y} = object;', which declares variables
+// from the map entries or instance properties of the same names.
+func (p *Parser) patternDeclaration(pattern *Token) Stmt {
+	closing, closingText := RIGHT_BRACKET, "]"
+	if pattern.tokenType == LEFT_BRACE {
+		closing, closingText = RIGHT_BRACE, "}"
+	}
+
+	var names []*Token
+	for {
+		names = p.appendUniqueName(names, p.consume(IDENTIFIER, "Expect variable name."), "variable")
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(closing, fmt.Sprintf("Expect %v'%v'%v after variable names.", YELLOW, closingText, RESET))
+	equals := p.consume(EQUAL, fmt.Sprintf("Expect %v'='%v after destructuring pattern.", YELLOW, RESET))
+	initializer := p.expression()
+
+	p.consume(SEMICOLON, fmt.Sprintf("Expected %v';'%v after variable declaration.", YELLOW, RESET))
+	return &DestructureStmt{pattern: pattern, names: names, equals: equals, initializer: initializer}
+}
+
+func (p *Parser) whileStatement(label *Token) Stmt {
+	keyword := p.previous()
 	p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after '%v'while'%v.", YELLOW, RESET, YELLOW, RESET))
 	condition := p.expression()
 	p.consume(RIGHT_PAREN, fmt.Sprintf("Expect %v')'%v after condition.", YELLOW, RESET))
 
-	p.loopDepth++
+	p.enterLoop(label, false)
 	body := p.statement()
-	p.loopDepth--
+	p.exitLoop()
 
 	return &WhileStmt{
+		keyword:   keyword,
 		condition: condition,
 		body:      body,
+		label:     label,
+	}
+}
+
+// tryStatement parses 'try { ... }' followed by a 'catch (name) { ... }'
+// clause, a 'finally { ... }' clause or both.
+func (p *Parser) tryStatement() Stmt {
+	keyword := p.previous()
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v after %v'try'%v.", YELLOW, RESET, YELLOW, RESET))
+	stmt := &TryStmt{keyword: keyword, body: p.newBlock(p.block())}
+
+	if p.match(CATCH) {
+		p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after %v'catch'%v.", YELLOW, RESET, YELLOW, RESET))
+		stmt.catchName = p.consume(IDENTIFIER, "Expect error variable name.")
+		p.consume(RIGHT_PAREN, fmt.Sprintf("Expect %v')'%v after error variable name.", YELLOW, RESET))
+		p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v before catch body.", YELLOW, RESET))
+		stmt.catchBody = p.newBlock(p.block())
+	}
+	if p.match(FINALLY) {
+		p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{'%v after %v'finally'%v.", YELLOW, RESET, YELLOW, RESET))
+		stmt.finallyBody = p.newBlock(p.block())
+	}
+	if stmt.catchBody == nil && stmt.finallyBody == nil {
+		p.error(p.peek(), fmt.Sprintf("Expect %v'catch'%v or %v'finally'%v after try block.", YELLOW, RESET, YELLOW, RESET))
+	}
+	return stmt
+}
+
+// usingStatement parses a 'using' resource block.
+func (p *Parser) usingStatement() Stmt {
+	keyword := p.previous()
+	p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after %v'using'%v.", YELLOW, RESET, YELLOW, RESET))
+	p.consume(VAR, fmt.Sprintf("Expect %v'var'%v in resource declaration.", YELLOW, RESET))
+	name := p.consume(IDENTIFIER, "Expect resource name.")
+	p.consume(EQUAL, fmt.Sprintf("Expect %v'='%v after resource name.", YELLOW, RESET))
+	initializer := p.expression()
+	p.consume(RIGHT_PAREN, fmt.Sprintf("Expect %v')'%v after resource declaration.", YELLOW, RESET))
+
+	return &UsingStmt{
+		keyword:     keyword,
+		name:        name,
+		initializer: initializer,
+		body:        p.statement(),
 	}
 }
 
@@ -226,32 +723,160 @@ func (p *Parser) expressionStatement() Stmt {
 
 func (p *Parser) function(kind string) Stmt {
 	name := p.consume(IDENTIFIER, fmt.Sprintf("Expect %v name.", kind))
-	p.consume(LEFT_PAREN, fmt.Sprintf("Expect '(' after %v name.", kind))
-	
+
+	// A method name followed directly by its body declares a getter.
+	isGetter := kind == "method" && p.check(LEFT_BRACE)
+	var parameters []*Token
+	if !isGetter {
+		p.consume(LEFT_PAREN, fmt.Sprintf("Expect '(' after %v name.", kind))
+		parameters = p.parameters()
+	}
+	return p.functionBody(kind, name, parameters, isGetter)
+}
+
+// lambda parses an anonymous function expression such as
+// 'fun (a, b) { return a + b; }'.
+func (p *Parser) lambda(keyword *Token) Expr {
+	p.consume(LEFT_PAREN, fmt.Sprintf("Expect %v'('%v after %v'fun'%v.", YELLOW, RESET, YELLOW, RESET))
+	parameters := p.parameters()
+	name := &Token{tokenType: IDENTIFIER, lexeme: "anonymous", line: keyword.line, column: keyword.column}
+	return &FunctionExpr{declaration: p.functionBody("function", name, parameters, false)}
+}
+
+// checkArrowFunction reports whether the tokens ahead start an arrow
+// function, '(a, b) -> a + b', rather than a parenthesized expression.
+func (p *Parser) checkArrowFunction() bool {
+	if !p.check(LEFT_PAREN) {
+		return false
+	}
+	ahead := p.current + 1
+	if p.tokens[ahead].tokenType != RIGHT_PAREN {
+		for p.tokens[ahead].tokenType == IDENTIFIER && p.tokens[ahead+1].tokenType == COMMA {
+			ahead += 2
+		}
+		if p.tokens[ahead].tokenType != IDENTIFIER {
+			return false
+		}
+		ahead++
+	}
+	return p.tokens[ahead].tokenType == RIGHT_PAREN && p.tokens[ahead+1].tokenType == ARROW
+}
+
+// arrowFunction parses '(a, b) -> expression', shorthand for an anonymous
+// function that returns the expression.
+func (p *Parser) arrowFunction() Expr {
+	keyword := p.advance()
+	parameters := p.parameters()
+	arrow := p.consume(ARROW, fmt.Sprintf("Expect %v'->'%v after parameters.", YELLOW, RESET))
+
+	enclosingFunction := p.currentFunction
+	p.currentFunction = FUNCTION_FUNCTION
+	// break and continue can't reach loops outside the function.
+	enclosingLoops := p.loops
+	p.loops = nil
+	value := p.expression()
+	p.currentFunction = enclosingFunction
+	p.loops = enclosingLoops
+
+	name := &Token{tokenType: IDENTIFIER, lexeme: "anonymous", line: keyword.line, column: keyword.column}
+	return &FunctionExpr{declaration: &FunctionStmt{
+		name:   name,
+		params: parameters,
+		body:   []Stmt{&ReturnStmt{keyword: arrow, value: value}},
+	}}
+}
+
+// functionBody parses the contract clauses and body of a function, method
+// or lambda, after its parameters.
+func (p *Parser) functionBody(kind string, name *Token, parameters []*Token, isGetter bool) *FunctionStmt {
+	// Contract clauses sit between the parameters and the body.
+	var requires []Expr
+	var ensures []Expr
+	for {
+		if p.match(REQUIRES) {
+			requires = append(requires, p.expression())
+		} else if p.match(ENSURES) {
+			ensures = append(ensures, p.expression())
+		} else {
+			break
+		}
+	}
+
+	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{%v after %v body.", YELLOW, RESET, kind))
+
+	enclosingFunction := p.currentFunction
+	p.currentFunction = FUNCTION_FUNCTION
+	if kind == "method" {
+		p.currentFunction = FUNCTION_METHOD
+		if name.lexeme == "init" {
+			p.currentFunction = FUNCTION_INITIALIZER
+		}
+	}
+	// break and continue can't reach loops outside the function.
+	enclosingLoops := p.loops
+	p.loops = nil
+	body := p.block()
+	p.currentFunction = enclosingFunction
+	p.loops = enclosingLoops
+	return &FunctionStmt{
+		name:     name,
+		params:   parameters,
+		body:     body,
+		requires: requires,
+		ensures:  ensures,
+		isGetter: isGetter,
+	}
+}
+
+// parameters parses a parameter list after its opening '('.
+func (p *Parser) parameters() []*Token {
 	var parameters []*Token
 	if !p.check(RIGHT_PAREN) {
 		// Handle first parameter
 		if len(parameters) >= 255 {
-			log.Fatal(ReportExit(p.peek().line, "", "Can't have more than 255 parameters."))
+			p.error(p.peek(), "Can't have more than 255 parameters.")
 		}
-		parameters = append(parameters, p.consume(IDENTIFIER, "Expect parameter name."))
-		
+		parameters = p.appendUniqueName(parameters, p.consume(IDENTIFIER, "Expect parameter name."), "parameter")
+
 		// Handle any additional parameters
 		for p.match(COMMA) {
 			if len(parameters) >= 255 {
-				log.Fatal(ReportExit(p.peek().line, "", "Can't have more than 255 parameters."))
+				p.error(p.peek(), "Can't have more than 255 parameters.")
 			}
-			parameters = append(parameters, p.consume(IDENTIFIER, "Expect parameter name."))
+			parameters = p.appendUniqueName(parameters, p.consume(IDENTIFIER, "Expect parameter name."), "parameter")
 		}
 	}
 
 	p.consume(RIGHT_PAREN, fmt.Sprintf("Expect ')' after parameters."))
-	p.consume(LEFT_BRACE, fmt.Sprintf("Expect %v'{%v after %v body.", YELLOW, RESET, kind))
-	body := p.block()
-	return &FunctionStmt{
-		name:   name,
-		params: parameters,
-		body:   body,
+
+	return parameters
+}
+
+// appendUniqueName appends name to names, rejecting names that are already bound.
+// Used wherever several names are introduced at once, such as parameter lists.
+func (p *Parser) appendUniqueName(names []*Token, name *Token, kind string) []*Token {
+	for _, existing := range names {
+		if existing.lexeme == name.lexeme {
+			p.error(name, fmt.Sprintf("Duplicate %v name %v'%v'%v.", kind, YELLOW, name.lexeme, RESET))
+		}
+	}
+	return append(names, name)
+}
+
+// newBlock creates a block statement, marking whether it needs its own scope.
+// Blocks that don't declare anything run in the enclosing environment, which
+// saves allocating an environment each time they execute.
+func (p *Parser) newBlock(statements []Stmt) *BlockStmt {
+	scoped := false
+	for _, statement := range statements {
+		switch statement.(type) {
+		case *VarStmt, *DestructureStmt, *FunctionStmt, *ClassStmt, *EnumStmt:
+			scoped = true
+		}
+	}
+	return &BlockStmt{
+		statements: statements,
+		scoped:     scoped,
 	}
 }
 
@@ -260,202 +885,270 @@ func (p *Parser) block() []Stmt {
 	var statements []Stmt
 
 	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
-		statements = append(statements, p.declaration())
+		if statement := p.declaration(); statement != nil {
+			statements = append(statements, statement)
+		}
 	}
 
 	p.consume(RIGHT_BRACE, fmt.Sprintf("Expected %v'}'%v after block.", YELLOW, RESET))
 	return statements
 }
 
-// assignment parses an assignment expression.
-func (p *Parser) assignment() Expr {
-	expr := p.or()
+// parsePrecedence parses an expression whose operators bind at least as
+// tightly as level, using the operator table in parseRules.
+func (p *Parser) parsePrecedence(level precedence) Expr {
+	var expr Expr
+	if rule := parseRules[p.peek().tokenType]; rule.prefix != nil {
+		expr = rule.prefix(p, p.advance())
+	} else {
+		expr = p.primary()
+	}
 
-	if p.match(EQUAL) {
-		equals := p.previous()
-		value := p.assignment()
-
-		token, ok := expr.(*VariableExpr)
-		if ok {
-			name := token.name
-			return &AssignExpr{
-				name:  name,
-				value: value,
-			}
+	for {
+		rule := parseRules[p.peek().tokenType]
+		if rule.infix == nil || rule.precedence < level {
+			return expr
 		}
-
-		log.Fatal(ReportExit(p.peek().line, "", fmt.Sprintf("%v[%v]%v Invalid assignment target.", YELLOW, equals, RESET)))
+		expr = rule.infix(p, expr, p.advance())
 	}
-
-	return expr
 }
 
-func (p *Parser) or() Expr {
-	expr := p.and()
-
-	for p.match(OR) {
-		operator := p.previous()
-		right := p.and()
-		expr = &LogicalExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
-		}
+// operand parses the right operand of an infix operator. Right-associative
+// operators take an operand at their own precedence, so 'a = b = c' means
+// 'a = (b = c)'; others need a tighter one, so 'a - b - c' means
+// '(a - b) - c'.
+func (p *Parser) operand(operator *Token) Expr {
+	rule := parseRules[operator.tokenType]
+	if rule.rightAssociative {
+		return p.parsePrecedence(rule.precedence)
 	}
-
-	return expr
+	return p.parsePrecedence(rule.precedence + 1)
 }
 
-func (p *Parser) and() Expr {
-	expr := p.equality()
+// assignment parses the value of an assignment to left.
+func (p *Parser) assignment(left Expr, equals *Token) Expr {
+	value := p.operand(equals)
 
-	for p.match(AND) {
-		operator := p.previous()
-		right := p.equality()
-		expr = &LogicalExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
+	if variable, ok := left.(*VariableExpr); ok {
+		return &AssignExpr{
+			name:  variable.name,
+			value: value,
 		}
 	}
 
-	return expr
-}
-
-// equality parses equality expressions (==, !=).
-func (p *Parser) equality() Expr {
-	expr := p.comparison()
-	for p.match(BANG_EQUAL, EQUAL_EQUAL) {
-		operator := p.previous()
-		right := p.comparison()
-		expr = &BinaryExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
+	if get, ok := left.(*GetExpr); ok {
+		return &SetExpr{
+			object: get.object,
+			name:   get.name,
+			value:  value,
 		}
 	}
 
-	return expr
-}
-
-// comparison parses comparison expressions (>, >=, <, <=).
-func (p *Parser) comparison() Expr {
-	expr := p.term()
-	for p.match(GREATER, GREATER_EQUAL, LESS, LESS_EQUAL) {
-		operator := p.previous()
-		right := p.term()
-		expr = &BinaryExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
+	if index, ok := left.(*IndexExpr); ok {
+		return &IndexSetExpr{
+			object:  index.object,
+			bracket: index.bracket,
+			index:   index.index,
+			value:   value,
 		}
 	}
 
-	return expr
+	p.error(equals, fmt.Sprintf("%v[%v]%v Invalid assignment target.", YELLOW, equals, RESET))
+	return left
 }
 
-// term parses addition and subtraction expressions.
-func (p *Parser) term() Expr {
-	expr := p.factor()
-	for p.match(MINUS, PLUS) {
-		operator := p.previous()
-		right := p.factor()
-		expr = &BinaryExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
-		}
-	}
-
-	return expr
+// conditional parses the rest of a 'condition ? then : else' expression.
+// It binds tighter than assignment and looser than 'or', and is right
+// associative, so 'a ? b : c ? d : e' means 'a ? b : (c ? d : e)'.
+func (p *Parser) conditional(condition Expr, question *Token) Expr {
+	thenBranch := p.expression()
+	p.consume(COLON, fmt.Sprintf("Expect %v':'%v after then branch of conditional expression.", YELLOW, RESET))
+	elseBranch := p.operand(question)
+	return &ConditionalExpr{condition: condition, thenBranch: thenBranch, elseBranch: elseBranch}
 }
 
-// factor parses multiplication and division expressions.
-func (p *Parser) factor() Expr {
-	expr := p.unary()
-	for p.match(SLASH, STAR) {
-		operator := p.previous()
-		right := p.unary()
-		expr = &BinaryExpr{
-			left:     expr,
-			operator: operator,
-			right:    right,
-		}
-	}
-
-	return expr
+// logical parses the right operand of 'and' or 'or'.
+func (p *Parser) logical(left Expr, operator *Token) Expr {
+	return p.logicalExprs.alloc(LogicalExpr{
+		left:     left,
+		operator: operator,
+		right:    p.operand(operator),
+	})
 }
 
-// unary parses unary expressions (!expr, -expr).
-func (p *Parser) unary() Expr {
-	if p.match(BANG, MINUS) {
-		operator := p.previous()
-		right := p.unary()
-		return &UnaryExpr{
-			operator: operator,
-			right:    right,
-		}
-	}
+// binary parses the right operand of an arithmetic, comparison, equality,
+// bitwise or exponent operator.
+func (p *Parser) binary(left Expr, operator *Token) Expr {
+	return p.binaryExprs.alloc(BinaryExpr{
+		left:     left,
+		operator: operator,
+		right:    p.operand(operator),
+	})
+}
 
-	return p.call()
+// unary parses the operand of a prefix operator (!, -, ~). '**' binds
+// tighter, so -2 ** 2 is -(2 ** 2), and 2 ** -1 is allowed.
+func (p *Parser) unary(operator *Token) Expr {
+	return p.unaryExprs.alloc(UnaryExpr{
+		operator: operator,
+		right:    p.parsePrecedence(PREC_UNARY),
+	})
 }
 
-func (p *Parser) finishCall(callee Expr) Expr {
+// finishCall parses the argument list of a call to callee. Calls and
+// property accesses share the tightest precedence, so a call's result can
+// be used directly, e.g. makeAdder(1)(2) or a.b().c().
+func (p *Parser) finishCall(callee Expr, _ *Token) Expr {
 	var arguments []Expr
+	start := p.current
 
 	if !p.check(RIGHT_PAREN) {
 		arguments = append(arguments, p.expression())
 		if len(arguments) >= 255 {
-			log.Fatal(ReportExit(p.peek().line, "", "Can't have more than 255 arguments."))
+			p.error(p.peek(), "Can't have more than 255 arguments.")
 		}
 		for p.match(COMMA) {
 			arguments = append(arguments, p.expression())
 		}
 	}
 	paren := p.consume(RIGHT_PAREN, fmt.Sprintf("Expect %v')'%v after arguments.", YELLOW, RESET))
-	return &CallExpr{
+	call := &CallExpr{
 		callee:    callee,
 		paren:     paren,
 		arguments: arguments,
 	}
+	if variable, ok := callee.(*VariableExpr); ok && variable.name.lexeme == "dbg" && len(arguments) == 1 {
+		// Keep the argument's source to show alongside its value.
+		return &DbgExpr{call: call, source: sourceText(p.tokens[start : p.current-1])}
+	}
+	return call
 }
 
-func (p *Parser) call() Expr {
-	expr := p.primary()
+// property parses the name in a property access on object.
+func (p *Parser) property(object Expr, _ *Token) Expr {
+	name := p.consume(IDENTIFIER, fmt.Sprintf("Expect property name after %v'.'%v.", YELLOW, RESET))
+	return &GetExpr{object: object, name: name}
+}
 
+// optionalProperty parses 'object?.name' and the calls, property accesses
+// and indexes chained after it, which are all skipped when object is nil.
+func (p *Parser) optionalProperty(object Expr, _ *Token) Expr {
+	name := p.consume(IDENTIFIER, fmt.Sprintf("Expect property name after %v'?.'%v.", YELLOW, RESET))
+	var chain Expr = &GetExpr{object: object, name: name, optional: true}
 	for {
-		if p.match(LEFT_PAREN) {
-			expr = p.finishCall(expr)
-		} else {
+		rule := parseRules[p.peek().tokenType]
+		if rule.infix == nil || rule.precedence != PREC_CALL {
+			return &OptionalChainExpr{expression: chain}
+		}
+		chain = rule.infix(p, chain, p.advance())
+	}
+}
+
+// index parses the index in 'object[index]', or the bounds of a slice
+// 'object[start:end]', where either bound can be left out.
+func (p *Parser) index(object Expr, _ *Token) Expr {
+	var start Expr
+	if !p.check(COLON) {
+		start = p.expression()
+	}
+	if !p.match(COLON) {
+		bracket := p.consume(RIGHT_BRACKET, fmt.Sprintf("Expect %v']'%v after index.", YELLOW, RESET))
+		return &IndexExpr{object: object, bracket: bracket, index: start}
+	}
+
+	var end Expr
+	if !p.check(RIGHT_BRACKET) {
+		end = p.expression()
+	}
+	bracket := p.consume(RIGHT_BRACKET, fmt.Sprintf("Expect %v']'%v after slice.", YELLOW, RESET))
+	return &SliceExpr{object: object, bracket: bracket, start: start, end: end}
+}
+
+// list parses the elements of a list literal such as '[1, 2, 3]'.
+// A trailing comma is allowed.
+func (p *Parser) list(bracket *Token) Expr {
+	var elements []Expr
+	for !p.check(RIGHT_BRACKET) {
+		elements = append(elements, p.expression())
+		if !p.match(COMMA) {
 			break
 		}
 	}
+	p.consume(RIGHT_BRACKET, fmt.Sprintf("Expect %v']'%v after list elements.", YELLOW, RESET))
+	return &ListExpr{bracket: bracket, elements: elements}
+}
 
-	return expr
+// mapLiteral parses the entries of a map literal such as
+// '{"name": "Lox", "year": 2015}'. A trailing comma is allowed. In statement
+// position a '{' starts a block instead.
+func (p *Parser) mapLiteral(brace *Token) Expr {
+	var keys, values []Expr
+	for !p.check(RIGHT_BRACE) {
+		keys = append(keys, p.expression())
+		p.consume(COLON, fmt.Sprintf("Expect %v':'%v after map key.", YELLOW, RESET))
+		values = append(values, p.expression())
+		if !p.match(COMMA) {
+			break
+		}
+	}
+	p.consume(RIGHT_BRACE, fmt.Sprintf("Expect %v'}'%v after map entries.", YELLOW, RESET))
+	return &MapExpr{brace: brace, keys: keys, values: values}
 }
 
 // primary parses primary expressions (literals, grouping).
 func (p *Parser) primary() Expr {
 	if p.match(FALSE) {
-		return &LiteralExpr{value: false}
+		return p.literalExprs.alloc(LiteralExpr{value: false})
 	}
 
 	if p.match(TRUE) {
-		return &LiteralExpr{value: true}
+		return p.literalExprs.alloc(LiteralExpr{value: true})
 	}
 
 	if p.match(NIL) {
-		return &LiteralExpr{value: nil}
+		return p.literalExprs.alloc(LiteralExpr{value: nil})
 	}
 
 	if p.match(NUMBER, STRING) {
-		return &LiteralExpr{
+		return p.literalExprs.alloc(LiteralExpr{
 			value: p.previous().literal,
+		})
+	}
+
+	if p.match(SUPER) {
+		keyword := p.previous()
+		if p.currentClass == CLASS_NONE {
+			p.error(keyword, fmt.Sprintf("Can't use %v'super'%v outside of a class.", YELLOW, RESET))
+		} else if p.currentClass != CLASS_SUBCLASS {
+			p.error(keyword, fmt.Sprintf("Can't use %v'super'%v in a class with no superclass.", YELLOW, RESET))
 		}
+		p.consume(DOT, fmt.Sprintf("Expect %v'.'%v after 'super'.", YELLOW, RESET))
+		method := p.consume(IDENTIFIER, "Expect superclass method name.")
+		return &SuperExpr{keyword: keyword, method: method}
+	}
+
+	if p.match(THIS) {
+		keyword := p.previous()
+		if p.currentClass == CLASS_NONE {
+			p.error(keyword, fmt.Sprintf("Can't use %v'this'%v outside of a class.", YELLOW, RESET))
+		}
+		return &ThisExpr{keyword: keyword}
 	}
 
 	if p.match(IDENTIFIER) {
-		return &VariableExpr{p.previous()}
+		return p.variableExprs.alloc(VariableExpr{p.previous()})
+	}
+
+	if p.match(LOOP) {
+		return p.loopExpression(nil, true)
+	}
+
+	if p.match(MATCH) {
+		return p.matchExpression()
+	}
+
+	if p.checkArrowFunction() {
+		return p.arrowFunction()
 	}
 
 	if p.match(LEFT_PAREN) {
@@ -464,8 +1157,8 @@ func (p *Parser) primary() Expr {
 		return &GroupingExpr{expression: expr}
 	}
 
-	log.Fatal(ReportExit(p.peek().line, "", "Expected expression."))
-	return nil
+	p.error(p.peek(), "Expected expression.")
+	panic(parseError{})
 }
 
 // match checks if the current token matches any of the given types.
@@ -488,8 +1181,8 @@ func (p *Parser) consume(tokenType TokenType, message string) *Token {
 		return p.advance()
 	}
 
-	log.Fatal(ReportExit(p.peek().line, "", message))
-	return nil
+	p.error(p.peek(), message)
+	panic(parseError{})
 }
 
 // check checks if the current token is of the expected type.
@@ -508,6 +1201,14 @@ func (p *Parser) advance() *Token {
 	return p.previous()
 }
 
+// checkNext checks if the token after the current one is of the given type.
+func (p *Parser) checkNext(ttype TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.tokens[p.current+1].tokenType == ttype
+}
+
 // isAtEnd checks if we've reached the end of the token list.
 func (p *Parser) isAtEnd() bool {
 	return p.peek().tokenType == EOF
@@ -534,16 +1235,17 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().tokenType {
-		case CLASS:
-		case FUN:
-		case VAR:
-		case IF:
-		case WHILE:
-		case PRINT:
-		case RETURN:
+		case CLASS, ENUM, FUN, VAR, CONST, FOR, IF, WHILE, PRINT, RETURN, TRY, THROW:
 			return
 		}
 
 		p.advance()
 	}
 }
+
+// error reports a syntax error. Errors that leave the parser in a known
+// state are only reported; the rest also panic with parseError.
+func (p *Parser) error(token *Token, message string) {
+	ReportError(token.line, token.column, "", message)
+	p.hadError = true
+}
@@ -0,0 +1,77 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// recordFailureScope stores environment on a runtime error passing through
+// the block that created it, unless an inner block already did. It must be
+// deferred directly, and re-raises whatever it recovers.
+func recordFailureScope(environment *Environment) {
+	if r := recover(); r != nil {
+		if err, ok := r.(*RuntimeError); ok && err.environment == nil {
+			err.environment = environment
+		}
+		panic(r)
+	}
+}
+
+// runPostMortem opens a REPL in the scope where err was raised, for
+// '--post-mortem', so the variables there can be inspected or changed.
+// The value of each input is echoed back, and a trailing ';' may be left
+// out. It returns at end of input or on ':quit', and the script still
+// exits with its runtime error.
+func (lox *Lox) runPostMortem(err *RuntimeError) {
+	interpreter := lox.interpreter
+	scope := err.environment
+	if scope == nil {
+		scope = interpreter.globals
+	}
+	flushPrints := interpreter.flushPrints
+	interpreter.flushPrints = true // Prints must come before the next prompt.
+	defer func() {
+		interpreter.environment = interpreter.globals
+		interpreter.flushPrints = flushPrints
+	}()
+
+	fmt.Fprintln(os.Stderr, "Post-mortem: inspecting the scope of the error. Enter :quit or end input to exit.")
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("(post-mortem) > ")
+		line, readErr := reader.ReadString('\n')
+		if readErr == io.EOF && line == "" {
+			fmt.Println()
+			return
+		} else if readErr != nil && readErr != io.EOF {
+			log.Fatal("Error reading input: ", readErr)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == ":quit" {
+			return
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, ";") && !strings.HasSuffix(line, "}") {
+			line += ";" // Let a bare expression be entered to see its value.
+		}
+		statements, ok := load(line, lox)
+		if !ok {
+			continue
+		}
+		interpreter.environment = scope
+		value, runErr := interpreter.Interpret(statements)
+		if runErr != nil {
+			log.Print(runErr.Error())
+		} else if value != nil {
+			fmt.Printf("=> %v\n", stringifyValue(value))
+		}
+	}
+}
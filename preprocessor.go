@@ -0,0 +1,383 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Defines holds the compile-time constants passed on the command line with
+// -D NAME=value. It implements flag.Value so -D can be repeated.
+type Defines map[string]interface{}
+
+// String lists the defines as NAME=value pairs.
+func (d Defines) String() string {
+	var pairs []string
+	for name, value := range d {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", name, stringifyValue(value)))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set parses one NAME=value define. The value is read as a Lox literal:
+// true, false, nil or a number, and anything else is a string.
+// A bare NAME defines it as true.
+func (d Defines) Set(define string) error {
+	name, text, found := strings.Cut(define, "=")
+	if !isIdentifier(name) {
+		return fmt.Errorf("invalid constant name '%v'", name)
+	}
+
+	var value interface{} = true
+	if found {
+		value = text
+		switch text {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "nil":
+			value = nil
+		default:
+			if number, err := strconv.ParseFloat(text, 64); err == nil {
+				value = number
+			}
+		}
+	}
+	d[name] = value
+	return nil
+}
+
+// Preprocessor resolves compile-time directives between the scanner and the
+// parser:
+//
+//	const NAME = expression;          // define a constant
+//	@if NAME { ... } @else { ... }    // keep only one of the blocks
+//	@if A { ... } @else if B { ... }  // '@else @if' works too
+//
+// const declarations are kept for the parser, which makes them variables
+// that can't be assigned to, and their expressions are remembered so @if
+// conditions can use them for the rest of the block declaring them.
+// Constants defined on the command line take
+// precedence over const declarations, so scripts can declare defaults. The
+// tokens of a kept block are spliced into the enclosing code without their
+// braces.
+type Preprocessor struct {
+	stream    *TokenStream
+	constants map[string][]*Token // Expression of each constant in scope, for @if conditions
+	shadowed  []shadowedConstant  // Constants to restore when the blocks declaring them end
+	defines   Defines             // Constants defined on the command line
+	output    []*Token            // Tokens left after resolving directives
+	depth     int                 // Brace nesting depth of the output
+	openIfs   []int               // Depths at which kept @if blocks were opened
+	hadError  bool                // Whether a directive was malformed
+}
+
+// shadowedConstant records the constant a const declaration replaced, to
+// be restored at the end of the declaring block.
+type shadowedConstant struct {
+	name       string
+	depth      int      // Block depth of the declaration
+	expression []*Token // Expression of the replaced constant, nil if there was none
+}
+
+// NewPreprocessor creates a Preprocessor with the given predefined constants.
+func NewPreprocessor(defines Defines) *Preprocessor {
+	preprocessor := &Preprocessor{constants: map[string][]*Token{}, defines: defines}
+	for name, value := range defines {
+		preprocessor.constants[name] = []*Token{literalToken(value, &Token{})}
+	}
+	return preprocessor
+}
+
+// Process resolves every directive in tokens and returns the tokens to parse.
+func (p *Preprocessor) Process(tokens []*Token) []*Token {
+	p.stream = NewTokenStream(tokens)
+	for !p.stream.AtEnd() {
+		p.token(p.stream.Next())
+	}
+	if len(p.openIfs) > 0 {
+		p.error(p.stream.Peek(0), fmt.Sprintf("Expect %v'}'%v to close %v'@if'%v block.", YELLOW, RESET, YELLOW, RESET))
+	}
+	return append(p.output, p.stream.Next())
+}
+
+// token handles a single token from the input.
+func (p *Preprocessor) token(token *Token) {
+	switch token.tokenType {
+	case CONST:
+		p.constDirective(token)
+	case AT:
+		p.ifDirective(token)
+	case LEFT_BRACE:
+		p.depth++
+		p.output = append(p.output, token)
+	case RIGHT_BRACE:
+		if len(p.openIfs) > 0 && p.openIfs[len(p.openIfs)-1] == p.depth {
+			// End of a kept @if block: drop its brace and any @else branches.
+			p.openIfs = p.openIfs[:len(p.openIfs)-1]
+			p.depth--
+			p.skipElseBranches()
+			return
+		}
+		p.depth--
+		p.endBlock()
+		p.output = append(p.output, token)
+	default:
+		p.output = append(p.output, token)
+	}
+}
+
+// constDirective handles 'const NAME = expression;'. The declaration is
+// passed on to the parser, with the value from the command line instead of
+// expression when there is one.
+func (p *Preprocessor) constDirective(keyword *Token) {
+	name := p.stream.Next()
+	if name.tokenType != IDENTIFIER {
+		p.error(name, "Expect constant name.")
+		return
+	}
+	equals := p.stream.Next()
+	if equals.tokenType != EQUAL {
+		p.error(equals, fmt.Sprintf("Expect %v'='%v after constant name.", YELLOW, RESET))
+		return
+	}
+
+	expression := p.collect(SEMICOLON)
+	semicolon := p.stream.Next()
+	if semicolon.tokenType != SEMICOLON {
+		p.error(keyword, fmt.Sprintf("Expect %v';'%v after constant value.", YELLOW, RESET))
+		return
+	}
+	if value, ok := p.defines[name.lexeme]; ok {
+		// The command line overrides the script's default.
+		expression = []*Token{literalToken(value, equals)}
+	}
+	p.shadowed = append(p.shadowed, shadowedConstant{name: name.lexeme, depth: p.blockDepth(), expression: p.constants[name.lexeme]})
+	p.constants[name.lexeme] = p.expand(expression)
+
+	p.output = append(p.output, keyword, name, equals)
+	for _, token := range expression {
+		p.token(token)
+	}
+	p.output = append(p.output, semicolon)
+}
+
+// blockDepth is the nesting depth of the blocks in the output, which
+// doesn't count the kept @if blocks whose braces are dropped.
+func (p *Preprocessor) blockDepth() int {
+	return p.depth - len(p.openIfs)
+}
+
+// endBlock forgets the constants declared in a block that just ended,
+// bringing back the ones they shadowed.
+func (p *Preprocessor) endBlock() {
+	for len(p.shadowed) > 0 && p.shadowed[len(p.shadowed)-1].depth > p.blockDepth() {
+		last := p.shadowed[len(p.shadowed)-1]
+		p.shadowed = p.shadowed[:len(p.shadowed)-1]
+		if last.expression == nil {
+			delete(p.constants, last.name)
+		} else {
+			p.constants[last.name] = last.expression
+		}
+	}
+}
+
+// expand replaces the constants used in tokens with their expressions, in
+// parentheses. Property names that share a constant's name are left alone.
+func (p *Preprocessor) expand(tokens []*Token) []*Token {
+	var expanded []*Token
+	for index, token := range tokens {
+		expression, ok := p.constants[token.lexeme]
+		if !ok || token.tokenType != IDENTIFIER || (index > 0 && tokens[index-1].tokenType == DOT) {
+			expanded = append(expanded, token)
+			continue
+		}
+		open := NewToken(LEFT_PAREN, "(", nil, token.line)
+		open.column = token.column
+		close := NewToken(RIGHT_PAREN, ")", nil, token.line)
+		close.column = token.column
+		expanded = append(expanded, open)
+		expanded = append(expanded, expression...)
+		expanded = append(expanded, close)
+	}
+	return expanded
+}
+
+// ifDirective handles '@if condition { ... }' and its '@else' branches.
+func (p *Preprocessor) ifDirective(at *Token) {
+	if p.stream.Next().tokenType != IF {
+		p.error(at, fmt.Sprintf("Expect %v'if'%v after %v'@'%v.", YELLOW, RESET, YELLOW, RESET))
+		return
+	}
+
+	for {
+		condition, ok := p.evaluate(p.collect(LEFT_BRACE), at)
+		if p.stream.Next().tokenType != LEFT_BRACE {
+			p.error(at, fmt.Sprintf("Expect %v'{'%v after %v'@if'%v condition.", YELLOW, RESET, YELLOW, RESET))
+			return
+		}
+		if ok && p.isTruthy(condition) {
+			p.openBlock()
+			return
+		}
+		p.skipBlock()
+
+		if !p.matchElse() {
+			return
+		}
+		if !p.matchElseIf() {
+			if p.stream.Next().tokenType != LEFT_BRACE {
+				p.error(at, fmt.Sprintf("Expect %v'{'%v after %v'@else'%v.", YELLOW, RESET, YELLOW, RESET))
+				return
+			}
+			p.openBlock()
+			return
+		}
+	}
+}
+
+// openBlock starts copying a kept block whose '{' was just consumed.
+func (p *Preprocessor) openBlock() {
+	p.depth++
+	p.openIfs = append(p.openIfs, p.depth)
+}
+
+// skipBlock discards a block whose '{' was just consumed.
+func (p *Preprocessor) skipBlock() {
+	for depth := 1; depth > 0 && !p.stream.AtEnd(); {
+		switch p.stream.Next().tokenType {
+		case LEFT_BRACE:
+			depth++
+		case RIGHT_BRACE:
+			depth--
+		}
+	}
+}
+
+// skipElseBranches discards the '@else' branches after a kept block.
+func (p *Preprocessor) skipElseBranches() {
+	for p.matchElse() {
+		if p.matchElseIf() {
+			p.collect(LEFT_BRACE)
+		}
+		if p.stream.Next().tokenType == LEFT_BRACE {
+			p.skipBlock()
+		}
+	}
+}
+
+// matchElse consumes '@else' if it comes next.
+func (p *Preprocessor) matchElse() bool {
+	if p.stream.Peek(0).tokenType == AT && p.stream.Peek(1).tokenType == ELSE {
+		p.stream.Next()
+		p.stream.Next()
+		return true
+	}
+	return false
+}
+
+// matchElseIf consumes the 'if' or '@if' of an '@else if' branch.
+func (p *Preprocessor) matchElseIf() bool {
+	if p.stream.Peek(0).tokenType == AT && p.stream.Peek(1).tokenType == IF {
+		p.stream.Next()
+	}
+	if p.stream.Peek(0).tokenType == IF {
+		p.stream.Next()
+		return true
+	}
+	return false
+}
+
+// collect consumes the tokens up to, but not including, the first token of
+// the given type outside parentheses, brackets and braces.
+func (p *Preprocessor) collect(end TokenType) []*Token {
+	var tokens []*Token
+	nesting := 0
+	for !p.stream.AtEnd() {
+		token := p.stream.Peek(0)
+		if token.tokenType == end && nesting == 0 {
+			break
+		}
+		switch token.tokenType {
+		case LEFT_PAREN, LEFT_BRACKET, LEFT_BRACE:
+			nesting++
+		case RIGHT_PAREN, RIGHT_BRACKET, RIGHT_BRACE:
+			nesting--
+		}
+		tokens = append(tokens, p.stream.Next())
+	}
+	return tokens
+}
+
+// evaluate computes the value of an @if condition. It may use constants
+// whose values are known at compile time, but not variables or functions.
+func (p *Preprocessor) evaluate(tokens []*Token, position *Token) (value interface{}, ok bool) {
+	parser := NewParser(NewTokenStream(p.expand(tokens)).Rest())
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isParseError := r.(parseError); !isParseError {
+				panic(r) // re-panic if it's not a syntax error
+			}
+			p.hadError = true
+			value, ok = nil, false
+		}
+	}()
+
+	expr := parser.expression()
+	if !parser.isAtEnd() {
+		parser.error(parser.peek(), "Expected end of constant expression.")
+	}
+	if parser.hadError {
+		p.hadError = true
+		return nil, false
+	}
+
+	literal, isLiteral := NewOptimizer(nil).fold(expr).(*LiteralExpr)
+	if !isLiteral {
+		p.error(position, "Expected a constant expression.")
+		return nil, false
+	}
+	return literal.value, true
+}
+
+// isTruthy follows Lox's rules: only false and nil are falsey.
+func (p *Preprocessor) isTruthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if boolean, ok := value.(bool); ok {
+		return boolean
+	}
+	return true
+}
+
+// error reports a malformed directive.
+func (p *Preprocessor) error(token *Token, message string) {
+	ReportError(token.line, token.column, "", message)
+	p.hadError = true
+}
+
+// literalToken creates the token for a constant's value, placed where the
+// constant was used.
+func literalToken(value interface{}, use *Token) *Token {
+	var token *Token
+	switch v := value.(type) {
+	case bool:
+		if v {
+			token = NewToken(TRUE, "true", nil, use.line)
+		} else {
+			token = NewToken(FALSE, "false", nil, use.line)
+		}
+	case float64:
+		token = NewToken(NUMBER, strconv.FormatFloat(v, 'f', -1, 64), v, use.line)
+	case string:
+		token = NewToken(STRING, strconv.Quote(v), v, use.line)
+	default:
+		token = NewToken(NIL, "nil", nil, use.line)
+	}
+	token.column = use.column
+	return token
+}
@@ -2,8 +2,13 @@
 package main
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Scanner performs lexical analysis on Lox source code.
@@ -14,31 +19,79 @@ type Scanner struct {
 	start    int       // Start position of the current lexeme
 	current  int       // Current position in the source
 	line     int       // Current line number being scanned
+	lineStart int      // Offset on the current line up to which lineRunes counts
+	lineRunes int      // Characters between the start of the line and lineStart
+	column   int       // Column where the current lexeme starts
 	keywords map[string]TokenType
+	foldKeywordCase bool // Look up keywords in lower case
+	hadError bool // Whether a syntax error was reported
+	tokenArena arena[Token] // Backing storage for the scanned tokens
 }
 
-// NewScanner creates a new Scanner instance for the given source code.
-func NewScanner(source string, lox *Lox) *Scanner {
-	keywords := map[string]TokenType{
-		"and":    AND,
-		"class":  CLASS,
-		"else":   ELSE,
-		"false":  FALSE,
-		"for":    FOR,
-		"fun":    FUN,
-		"if":     IF,
-		"nil":    NIL,
-		"or":     OR,
-		"print":  PRINT,
-		"return": RETURN,
-		"super":  SUPER,
-		"this":   THIS,
-		"true":   TRUE,
-		"var":    VAR,
-		"while":  WHILE,
-		"break":  BREAK,
+// keywords maps reserved words to their token types.
+// It is shared by every Scanner instead of being rebuilt per scan.
+var keywords = map[string]TokenType{
+	"and":      AND,
+	"class":    CLASS,
+	"else":     ELSE,
+	"false":    FALSE,
+	"for":      FOR,
+	"fun":      FUN,
+	"if":       IF,
+	"nil":      NIL,
+	"or":       OR,
+	"print":    PRINT,
+	"return":   RETURN,
+	"super":    SUPER,
+	"this":     THIS,
+	"true":     TRUE,
+	"var":      VAR,
+	"while":    WHILE,
+	"break":    BREAK,
+	"requires": REQUIRES,
+	"ensures":  ENSURES,
+	"loop":     LOOP,
+	"using":    USING,
+	"static":   STATIC,
+	"const":    CONST,
+	"continue": CONTINUE,
+	"import":   IMPORT,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"match":    MATCH,
+	"enum":     ENUM,
+}
+
+// singleCharTokens maps characters that always form a token on their own
+// to that token's type. Every other entry is EOF.
+var singleCharTokens = func() [256]TokenType {
+	var table [256]TokenType
+	for c := range table {
+		table[c] = EOF
 	}
+	table['('] = LEFT_PAREN
+	table[')'] = RIGHT_PAREN
+	table['{'] = LEFT_BRACE
+	table['}'] = RIGHT_BRACE
+	table['['] = LEFT_BRACKET
+	table[']'] = RIGHT_BRACKET
+	table[','] = COMMA
+	table['.'] = DOT
+	table['+'] = PLUS
+	table[';'] = SEMICOLON
+	table['@'] = AT
+	table[':'] = COLON
+	table['&'] = AMPERSAND
+	table['|'] = PIPE
+	table['^'] = CARET
+	table['~'] = TILDE
+	return table
+}()
 
+// NewScanner creates a new Scanner instance for the given source code.
+func NewScanner(source string, lox *Lox) *Scanner {
 	scanner := Scanner{
 		source:   source,
 		start:    0,
@@ -46,6 +99,10 @@ func NewScanner(source string, lox *Lox) *Scanner {
 		line:     1,
 		keywords: keywords,
 	}
+	if lox != nil && lox.dialect != nil {
+		scanner.keywords = lox.dialect.keywords
+		scanner.foldKeywordCase = lox.dialect.caseInsensitive
+	}
 
 	return &scanner
 }
@@ -55,10 +112,11 @@ func NewScanner(source string, lox *Lox) *Scanner {
 func (scanner *Scanner) ScanTokens() []*Token {
 	for !scanner.isAtEnd() {
 		scanner.start = scanner.current
+		scanner.column = scanner.columnAt(scanner.start)
 		scanner.scanToken()
 	}
 
-	scanner.tokens = append(scanner.tokens, NewToken(EOF, "", nil, scanner.line))
+	scanner.tokens = append(scanner.tokens, scanner.tokenArena.alloc(Token{tokenType: EOF, line: scanner.line, column: scanner.columnAt(len(scanner.source))}))
 	return scanner.tokens
 }
 
@@ -66,27 +124,32 @@ func (scanner *Scanner) ScanTokens() []*Token {
 // It identifies keywords, identifiers, literals, and operators.
 func (scanner *Scanner) scanToken() {
 	c := scanner.advance()
+
+	// Fast path for tokens that never need lookahead.
+	if tokenType := singleCharTokens[c]; tokenType != EOF {
+		scanner.addToken(tokenType)
+		return
+	}
+
 	switch c {
-	case '(':
-		scanner.addToken(LEFT_PAREN)
-	case ')':
-		scanner.addToken(RIGHT_PAREN)
-	case '{':
-		scanner.addToken(LEFT_BRACE)
-	case '}':
-		scanner.addToken(RIGHT_BRACE)
-	case ',':
-		scanner.addToken(COMMA)
-	case '.':
-		scanner.addToken(DOT)
 	case '-':
-		scanner.addToken(MINUS)
-	case '+':
-		scanner.addToken(PLUS)
-	case ';':
-		scanner.addToken(SEMICOLON)
+		if scanner.match('>') {
+			scanner.addToken(ARROW)
+		} else {
+			scanner.addToken(MINUS)
+		}
+	case '?':
+		if scanner.match('.') {
+			scanner.addToken(QUESTION_DOT)
+		} else {
+			scanner.addToken(QUESTION)
+		}
 	case '*':
-		scanner.addToken(STAR)
+		if scanner.match('*') {
+			scanner.addToken(STAR_STAR)
+		} else {
+			scanner.addToken(STAR)
+		}
 	case '!':
 		if scanner.match('=') {
 			scanner.addToken(BANG_EQUAL)
@@ -102,12 +165,16 @@ func (scanner *Scanner) scanToken() {
 	case '<':
 		if scanner.match('=') {
 			scanner.addToken(LESS_EQUAL)
+		} else if scanner.match('<') {
+			scanner.addToken(LESS_LESS)
 		} else {
 			scanner.addToken(LESS)
 		}
 	case '>':
 		if scanner.match('=') {
 			scanner.addToken(GREATER_EQUAL)
+		} else if scanner.match('>') {
+			scanner.addToken(GREATER_GREATER)
 		} else {
 			scanner.addToken(GREATER)
 		}
@@ -129,29 +196,61 @@ func (scanner *Scanner) scanToken() {
 	case '\r':
 	case '\t': // INFO: i have no clue if the cunt does the '\n' or just skips is. add break?
 	case '\n':
-		scanner.line++
+		scanner.newline()
 	case '"':
 		scanner.string()
+	case '`':
+		scanner.rawString()
 	default:
 		if scanner.isDigit(c) {
 			scanner.number()
 		} else if scanner.isAlpha(c) {
 			scanner.identifier()
+		} else if c >= utf8.RuneSelf {
+			scanner.unicode()
 		} else {
-			// scanner.lox.error(scanner.line, "Unexpected character.")
-			log.Fatal(ReportExit(scanner.line, "", "Unexpected character."))
+			scanner.error(scanner.line, "Unexpected character.")
 		}
 	}
 }
 
+// unicode handles a character outside ASCII, whose first byte has just been
+// consumed. Letters start an identifier; anything else is an error.
+func (scanner *Scanner) unicode() {
+	r, size := utf8.DecodeRuneInString(scanner.source[scanner.start:])
+	scanner.current = scanner.start + size
+	if r == utf8.RuneError && size == 1 {
+		scanner.error(scanner.line, "Invalid UTF-8 encoding.")
+	} else if unicode.IsLetter(r) {
+		scanner.identifier()
+	} else {
+		scanner.error(scanner.line, fmt.Sprintf("Unexpected character %v'%c'%v.", YELLOW, r, RESET))
+	}
+}
+
 // identifier handles identifier and keyword scanning.
-// It processes variable names and reserved keywords.
+// It processes variable names and reserved keywords, which may contain
+// Unicode letters, digits and combining marks.
 func (scanner *Scanner) identifier() {
-	for scanner.isAlphaNumeric(scanner.peek()) {
-		scanner.advance()
+	for {
+		if c := scanner.peek(); c < utf8.RuneSelf {
+			if !scanner.isAlphaNumeric(c) {
+				break
+			}
+			scanner.advance()
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(scanner.source[scanner.current:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.In(r, unicode.Mn, unicode.Mc) {
+			break
+		}
+		scanner.current += size
 	}
 
 	text := scanner.source[scanner.start:scanner.current]
+	if scanner.foldKeywordCase {
+		text = strings.ToLower(text)
+	}
 	tokenType, ok := scanner.keywords[text]
 	if !ok {
 		tokenType = IDENTIFIER
@@ -163,6 +262,20 @@ func (scanner *Scanner) identifier() {
 // number handles numeric literal scanning.
 // It processes both integer and decimal numbers.
 func (scanner *Scanner) number() {
+	if scanner.source[scanner.start] == '0' {
+		switch scanner.peek() {
+		case 'x', 'X':
+			scanner.radixNumber(16, "hexadecimal")
+			return
+		case 'b', 'B':
+			scanner.radixNumber(2, "binary")
+			return
+		case 'o', 'O':
+			scanner.radixNumber(8, "octal")
+			return
+		}
+	}
+
 	for scanner.isDigit(scanner.peek()) {
 		scanner.advance()
 	}
@@ -175,26 +288,72 @@ func (scanner *Scanner) number() {
 		}
 	}
 
+	// An exponent, e.g. 1.5e10 or 2E-3. Without digits after it, the 'e'
+	// starts an identifier instead.
+	if c := scanner.peek(); c == 'e' || c == 'E' {
+		digits := scanner.current + 1
+		if digits < len(scanner.source) && (scanner.source[digits] == '+' || scanner.source[digits] == '-') {
+			digits++
+		}
+		if digits < len(scanner.source) && scanner.isDigit(scanner.source[digits]) {
+			scanner.current = digits
+			for scanner.isDigit(scanner.peek()) {
+				scanner.advance()
+			}
+		}
+	}
+
 	number, err := strconv.ParseFloat(scanner.source[scanner.start:scanner.current], 64)
-	if err != nil {
-		log.Fatal(ReportExit(scanner.line, "", "Failed to parse float [scanner.number()].")) //? DEV?
+	if errors.Is(err, strconv.ErrRange) && math.IsInf(number, 0) {
+		scanner.error(scanner.line, fmt.Sprintf("The number %v'%v'%v is too large.", YELLOW, scanner.source[scanner.start:scanner.current], RESET))
+		return
+	} else if err != nil {
+		scanner.error(scanner.line, "Failed to parse float [scanner.number()].") //? DEV?
+		return
 	}
 
 	scanner.addTokenLiteral(NUMBER, number)
 }
 
+// radixNumber handles integer literals written in another base, such as
+// 0xFF, 0b1010 and 0o755. The '0' has been consumed and the base letter is
+// next.
+func (scanner *Scanner) radixNumber(base int, name string) {
+	scanner.advance() // consume the base letter
+	digitsStart := scanner.current
+	for scanner.isAlphaNumeric(scanner.peek()) {
+		scanner.advance()
+	}
+
+	digits := scanner.source[digitsStart:scanner.current]
+	if digits == "" {
+		scanner.error(scanner.line, fmt.Sprintf("Expect digits after %v'%v'%v in %v number.", YELLOW, scanner.source[scanner.start:digitsStart], RESET, name))
+		return
+	}
+	number, err := strconv.ParseUint(digits, base, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		scanner.error(scanner.line, fmt.Sprintf("The %v number %v'%v'%v is too large.", name, YELLOW, scanner.source[scanner.start:scanner.current], RESET))
+		return
+	} else if err != nil {
+		scanner.error(scanner.line, fmt.Sprintf("Invalid %v number %v'%v'%v.", name, YELLOW, scanner.source[scanner.start:scanner.current], RESET))
+		return
+	}
+
+	scanner.addTokenLiteral(NUMBER, float64(number))
+}
+
 // string handles string literal scanning.
 // It processes the characters between double quotes.
 func (scanner *Scanner) string() {
 	for scanner.peek() != '"' && !scanner.isAtEnd() {
-		if scanner.peek() == '\n' {
-			scanner.line++
+		if scanner.advance() == '\n' {
+			scanner.newline()
 		}
-		scanner.advance()
 	}
 
 	if scanner.isAtEnd() {
-		log.Fatal(ReportExit(scanner.line, "", "Unterminated string."))
+		scanner.error(scanner.line, "Unterminated string.")
+		return
 	}
 
 	scanner.advance()
@@ -203,6 +362,28 @@ func (scanner *Scanner) string() {
 	scanner.addTokenLiteral(STRING, value)
 }
 
+// rawString handles raw string literal scanning.
+// A raw string is written between backticks, may span several lines and
+// can contain double quotes. Carriage returns are dropped so a script means
+// the same thing with either line ending.
+func (scanner *Scanner) rawString() {
+	for scanner.peek() != '`' && !scanner.isAtEnd() {
+		if scanner.advance() == '\n' {
+			scanner.newline()
+		}
+	}
+
+	if scanner.isAtEnd() {
+		scanner.error(scanner.line, "Unterminated raw string.")
+		return
+	}
+
+	scanner.advance()
+
+	value := scanner.source[scanner.start+1 : scanner.current-1]
+	scanner.addTokenLiteral(STRING, strings.ReplaceAll(value, "\r", ""))
+}
+
 // match checks if the next character matches the expected one.
 // Returns true and advances the cursor if there's a match.
 func (scanner *Scanner) match(expected byte) bool {
@@ -275,6 +456,23 @@ func (scanner *Scanner) advanceNext() byte {
 	return ch
 }
 
+// newline moves the scanner's position to the line after a '\n' that was
+// just consumed.
+func (scanner *Scanner) newline() {
+	scanner.line++
+	scanner.lineStart = scanner.current
+	scanner.lineRunes = 0
+}
+
+// columnAt returns the column of the given offset on the current line.
+// It only counts the characters since the previous call, so finding
+// every token's column stays linear in the length of the line.
+func (scanner *Scanner) columnAt(offset int) int {
+	scanner.lineRunes += utf8.RuneCountInString(scanner.source[scanner.lineStart:offset])
+	scanner.lineStart = offset
+	return scanner.lineRunes + 1
+}
+
 // addToken adds a new token to the token list.
 // It creates a token with the current lexeme and given type.
 func (scanner *Scanner) addToken(tokenType TokenType) {
@@ -284,5 +482,19 @@ func (scanner *Scanner) addToken(tokenType TokenType) {
 // addTokenLiteral adds a new token with a literal value to the token list.
 func (scanner *Scanner) addTokenLiteral(tokenType TokenType, literal interface{}) {
 	text := scanner.source[scanner.start:scanner.current]
-	scanner.tokens = append(scanner.tokens, NewToken(tokenType, text, literal, scanner.line))
+	token := scanner.tokenArena.alloc(Token{
+		tokenType: tokenType,
+		lexeme:    text,
+		literal:   literal,
+		line:      scanner.line,
+		column:    scanner.column,
+	})
+	scanner.tokens = append(scanner.tokens, token)
+}
+
+// error reports a syntax error and keeps scanning, so that every error in
+// the source is reported in one run.
+func (scanner *Scanner) error(line int, message string) {
+	ReportError(line, scanner.column, "", message)
+	scanner.hadError = true
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// benchmarkSource is a mix of the tokens typical scripts are made of.
+const benchmarkSource = `// Compute a few squares.
+class Point {
+  init(x, y) {
+    this.x = x;
+    this.y = y;
+  }
+}
+
+fun square(n) {
+  return n * n;
+}
+
+var total = 0;
+for (var i = 0; i < 10; i = i + 1) {
+  if (i != 3 and i >= 1) total = total + square(i) / 2.5;
+}
+print "total: " + total;
+`
+
+func BenchmarkScanTokens(b *testing.B) {
+	source := strings.Repeat(benchmarkSource, 100)
+	b.SetBytes(int64(len(source)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		NewScanner(source, nil).ScanTokens()
+	}
+}
@@ -0,0 +1,87 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// resolveScriptPath turns a script argument into a path to open.
+// It accepts file:// URLs, as sent by editors over LSP, and normalizes
+// separators so forward slashes work on Windows too. UNC paths
+// (\\server\share\script.lox) are kept as they are.
+func resolveScriptPath(path string) (string, error) {
+	if strings.HasPrefix(path, "file:") {
+		location, err := url.Parse(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL '%v': %v", path, err)
+		}
+		if location.Scheme != "file" {
+			return "", fmt.Errorf("invalid file URL '%v'", path)
+		}
+
+		path = location.Path
+		if location.Host != "" && location.Host != "localhost" {
+			// file://server/share/script.lox names a network share.
+			path = "//" + location.Host + path
+		} else if runtime.GOOS == "windows" && len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+			// file:///C:/dir/script.lox: drop the slash before the drive letter.
+			path = path[1:]
+		}
+	}
+
+	return filepath.Clean(filepath.FromSlash(path)), nil
+}
+
+// readScript reads the script named by a command-line argument.
+// Errors include the path that was tried and the operating system's reason,
+// or where the file stops looking like Lox source. A UTF-8 byte order mark,
+// as some Windows editors write, is dropped.
+func readScript(path string) ([]byte, error) {
+	resolved, err := resolveScriptPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := os.ReadFile(resolved)
+	if err != nil {
+		var pathError *fs.PathError
+		if errors.As(err, &pathError) {
+			err = pathError.Err
+		}
+		return nil, fmt.Errorf("failed to read file '%v': %v", resolved, err)
+	}
+	source = bytes.TrimPrefix(source, []byte(utf8BOM))
+	if err := checkSource(source); err != nil {
+		return nil, fmt.Errorf("file '%v' does not appear to be a Lox source file: %v", resolved, err)
+	}
+	return source, nil
+}
+
+// utf8BOM is the byte order mark some editors start UTF-8 files with.
+const utf8BOM = "\uFEFF"
+
+// checkSource rejects input that can't be Lox source, so a binary file
+// gets one clear error instead of a scanner error per stray byte. Lox
+// source is UTF-8 text and never contains a NUL byte.
+func checkSource(source []byte) error {
+	if offset := bytes.IndexByte(source, 0); offset != -1 {
+		return fmt.Errorf("NUL byte at offset %v", offset)
+	}
+	for offset := 0; offset < len(source); {
+		r, size := utf8.DecodeRune(source[offset:])
+		if r == utf8.RuneError && size == 1 {
+			return fmt.Errorf("invalid UTF-8 at byte offset %v", offset)
+		}
+		offset += size
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Package main implements a Lox language interpreter
+package main
+
+import "maps"
+
+// Snapshot is a frozen copy of an interpreter's globals, e.g. after loading a
+// prelude or standard library. Forking a snapshot is cheap: the fork starts
+// with an empty global layer on top of the frozen one, so nothing is re-run
+// or copied up front.
+//
+// Bindings are copy-on-write: a fork that assigns to a snapshot global gets
+// its own binding and other forks keep seeing the original. Values themselves
+// aren't copied, so mutating a shared instance or map is visible to every
+// fork. Functions declared before the snapshot see the globals of the fork
+// that calls them, so they read and assign its bindings too.
+type Snapshot struct {
+	globals *Environment // The frozen global environment
+	base    *Interpreter // Interpreter the settings of forks are copied from
+}
+
+// Snapshot freezes the interpreter's current globals and returns a Snapshot
+// that can be forked. The interpreter keeps running on a copy-on-write layer
+// of its own, so it can be used as normal afterwards.
+// It must be called between runs, not while a script is executing.
+func (i *Interpreter) Snapshot() *Snapshot {
+	i.globals.frozen = true
+	snapshot := &Snapshot{globals: i.globals, base: i}
+
+	i.globals = newCopyOnWriteEnvironment(snapshot.globals)
+	i.environment = i.globals
+	return snapshot
+}
+
+// Fork creates a new interpreter that starts from the snapshot's globals.
+// It has the base interpreter's settings and script directory, and its own
+// copies of the scripts imported and the call counts so far.
+func (s *Snapshot) Fork() *Interpreter {
+	globals := newCopyOnWriteEnvironment(s.globals)
+	return &Interpreter{
+		natives:     s.base.natives,
+		globals:     globals,
+		environment: globals,
+		release:     s.base.release,
+		out:         s.base.out,
+		flushPrints: s.base.flushPrints,
+		iterative:   s.base.iterative,
+
+		forbidNativeShadowing: s.base.forbidNativeShadowing,
+		sandbox:               s.base.sandbox,
+		optionalArguments:     s.base.optionalArguments,
+		checkedIntegers:       s.base.checkedIntegers,
+		postMortem:            s.base.postMortem,
+		constants:             s.base.constants,
+		dialect:               s.base.dialect,
+		bundled:               s.base.bundled,
+		scriptDir:             s.base.scriptDir,
+		imported:              maps.Clone(s.base.imported),
+		callCounts:            maps.Clone(s.base.callCounts),
+		timeLimit:             s.base.timeLimit,
+		maxCallDepth:          s.base.maxCallDepth,
+	}
+}
+
+// newCopyOnWriteEnvironment creates a global layer on top of frozen globals.
+func newCopyOnWriteEnvironment(frozen *Environment) *Environment {
+	environment := NewEnclosingEnvironment(frozen)
+	environment.copyOnWrite = true
+	return environment
+}
@@ -0,0 +1,34 @@
+// Package main implements a Lox language interpreter
+package main
+
+import "runtime"
+
+// runStats counts work done by an interpreter, for the stats() native.
+type runStats struct {
+	statements uint64 // Statements executed
+	calls      uint64 // Calls to functions, classes and natives
+}
+
+// defineStatsNatives adds stats(), which returns a map of the
+// interpreter's counters and the Go runtime's memory statistics:
+//   - statements: statements executed so far
+//   - calls: calls made so far, including the call to stats()
+//   - heapBytes: bytes of live heap objects
+//   - totalAllocBytes: bytes allocated over the life of the process
+//   - allocations: heap objects allocated over the life of the process
+//   - gcCycles: completed garbage collections
+func defineStatsNatives(globals *Environment) {
+	globals.define("stats", NewNativeFunction("stats", 0, func(interpreter *Interpreter, arguments []interface{}) interface{} {
+		var memory runtime.MemStats
+		runtime.ReadMemStats(&memory)
+
+		stats := NewLoxMap()
+		stats.set("statements", float64(interpreter.stats.statements))
+		stats.set("calls", float64(interpreter.stats.calls))
+		stats.set("heapBytes", float64(memory.HeapAlloc))
+		stats.set("totalAllocBytes", float64(memory.TotalAlloc))
+		stats.set("allocations", float64(memory.Mallocs))
+		stats.set("gcCycles", float64(memory.NumGC))
+		return stats
+	}))
+}
@@ -0,0 +1,27 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"embed"
+	"path"
+)
+
+// stdlib holds the standard library modules written in Lox, which are
+// built into the interpreter.
+//
+//go:embed std/*.lox
+var stdlib embed.FS
+
+// stdlibPrefix starts the import path of a standard library module, e.g.
+// 'import "std/list";'. Such paths never refer to local files.
+const stdlibPrefix = "std/"
+
+// stdlibModule returns the file a standard library import path refers to.
+// The '.lox' extension may be left out of the path.
+func stdlibModule(location string) string {
+	name := path.Clean(location)
+	if path.Ext(name) != ".lox" {
+		name += ".lox"
+	}
+	return name
+}
@@ -2,14 +2,24 @@ package main
 
 type StmtVisitor interface {
 	VisitBlockStmt(*BlockStmt) interface{}
+	VisitClassStmt(*ClassStmt) interface{}
 	VisitExpressionStmt(*ExpressionStmt) interface{}
 	VisitFunctionStmt(*FunctionStmt) interface{}
 	VisitIfStmt(*IfStmt) interface{}
 	VisitPrintStmt(*PrintStmt) interface{}
 	VisitReturnStmt(*ReturnStmt) interface{}
 	VisitVarStmt(*VarStmt) interface{}
+	VisitEnumStmt(*EnumStmt) interface{}
+	VisitTryStmt(*TryStmt) interface{}
+	VisitThrowStmt(*ThrowStmt) interface{}
+	VisitDestructureStmt(*DestructureStmt) interface{}
 	VisitWhileStmt(*WhileStmt) interface{}
+	VisitForInStmt(*ForInStmt) interface{}
+	VisitUsingStmt(*UsingStmt) interface{}
 	VisitBreakStmt(*BreakStmt) interface{}
+	VisitContinueStmt(*ContinueStmt) interface{}
+	VisitImportStmt(*ImportStmt) interface{}
+	VisitImportNativeStmt(*ImportNativeStmt) interface{}
 }
 
 type Stmt interface {
@@ -18,6 +28,15 @@ type Stmt interface {
 
 type BlockStmt struct {
 	statements []Stmt
+	scoped bool
+	numericFor *numericFor
+}
+
+type ClassStmt struct {
+	name *Token
+	superclass *VariableExpr
+	methods []*FunctionStmt
+	staticMethods []*FunctionStmt
 }
 
 type ExpressionStmt struct {
@@ -28,6 +47,9 @@ type FunctionStmt struct {
 	name *Token
 	params []*Token
 	body []Stmt
+	requires []Expr
+	ensures []Expr
+	isGetter bool
 }
 
 type IfStmt struct {
@@ -48,20 +70,86 @@ type ReturnStmt struct {
 type VarStmt struct {
 	name *Token
 	initializer Expr
+	constant bool
+}
+
+type EnumStmt struct {
+	name *Token
+	members []*Token
+}
+
+type TryStmt struct {
+	keyword *Token
+	body Stmt
+	catchName *Token
+	catchBody Stmt
+	finallyBody Stmt
+}
+
+type ThrowStmt struct {
+	keyword *Token
+	value Expr
+}
+
+type DestructureStmt struct {
+	pattern *Token
+	names []*Token
+	equals *Token
+	initializer Expr
 }
 
 type WhileStmt struct {
+	keyword *Token
 	condition Expr
 	body Stmt
+	increment Expr
+	label *Token
+}
+
+type ForInStmt struct {
+	keyword *Token
+	name *Token
+	iterable Expr
+	body Stmt
+	label *Token
+}
+
+type UsingStmt struct {
+	keyword *Token
+	name *Token
+	initializer Expr
+	body Stmt
 }
 
 type BreakStmt struct {
+	keyword *Token
+	label *Token
+	value Expr
+}
+
+type ContinueStmt struct {
+	keyword *Token
+	label *Token
+}
+
+type ImportStmt struct {
+	keyword *Token
+	path *Token
+}
+
+type ImportNativeStmt struct {
+	keyword *Token
+	path *Token
 }
 
 func (b *BlockStmt) accept(visitor StmtVisitor) interface{} {
 	return visitor.VisitBlockStmt(b)
 }
 
+func (c *ClassStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitClassStmt(c)
+}
+
 func (e *ExpressionStmt) accept(visitor StmtVisitor) interface{} {
 	return visitor.VisitExpressionStmt(e)
 }
@@ -86,11 +174,47 @@ func (v *VarStmt) accept(visitor StmtVisitor) interface{} {
 	return visitor.VisitVarStmt(v)
 }
 
+func (e *EnumStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitEnumStmt(e)
+}
+
+func (t *TryStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitTryStmt(t)
+}
+
+func (t *ThrowStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitThrowStmt(t)
+}
+
+func (d *DestructureStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitDestructureStmt(d)
+}
+
 func (w *WhileStmt) accept(visitor StmtVisitor) interface{} {
 	return visitor.VisitWhileStmt(w)
 }
 
+func (f *ForInStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitForInStmt(f)
+}
+
+func (u *UsingStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitUsingStmt(u)
+}
+
 func (b *BreakStmt) accept(visitor StmtVisitor) interface{} {
 	return visitor.VisitBreakStmt(b)
 }
 
+func (c *ContinueStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitContinueStmt(c)
+}
+
+func (i *ImportStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitImportStmt(i)
+}
+
+func (i *ImportNativeStmt) accept(visitor StmtVisitor) interface{} {
+	return visitor.VisitImportNativeStmt(i)
+}
+
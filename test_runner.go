@@ -0,0 +1,158 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Test script annotations.
+const (
+	expectPrefix             = "// expect: "               // A line of expected output, e.g. 'print 1 + 2; // expect: 3'
+	expectRuntimeErrorPrefix = "// expect-runtime-error: " // The message of the runtime error the script must stop with
+	timeoutPrefix            = "// timeout: "              // How long the script may run, e.g. '// timeout: 2s'
+	optionPrefix             = "// option: "               // An interpreter option the script runs with, e.g. '// option: checked-ints'
+	maxCallDepthPrefix       = "// max-call-depth: "       // How deeply calls may nest, e.g. '// max-call-depth: 1000'
+)
+
+// testOptions are the interpreter options a test script can turn on with
+// an '// option: ' comment, named after their command line flags.
+var testOptions = map[string]Option{
+	"optional-args": WithOptionalArguments(),
+	"checked-ints":  WithCheckedIntegers(),
+}
+
+// runTestCommand runs 'jlox test [path...]', which runs test scripts and
+// checks what they print against their '// expect: ' comments. A script
+// may also expect to stop with a runtime error, and may set its own time
+// limit and interpreter options. Directories are searched for .lox files with at least one
+// expectation. It returns false if any test failed.
+func (lox *Lox) runTestCommand(args []string) bool {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	var scripts []string
+	for _, path := range args {
+		found, err := findTestScripts(path)
+		if err != nil {
+			fmt.Printf("FAIL  %v: %v\n", path, err)
+			return false
+		}
+		scripts = append(scripts, found...)
+	}
+
+	passed := 0
+	for _, script := range scripts {
+		if failure := lox.runTestScript(script); failure == "" {
+			passed++
+			fmt.Printf("ok    %v\n", script)
+		} else {
+			fmt.Printf("FAIL  %v\n%v", script, failure)
+		}
+	}
+	fmt.Printf("%v/%v passed\n", passed, len(scripts))
+	return passed == len(scripts)
+}
+
+// findTestScripts returns path if it's a file, or the test scripts inside
+// it if it's a directory.
+func findTestScripts(path string) ([]string, error) {
+	path, err := resolveScriptPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scripts []string
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file == path && !entry.IsDir() {
+			scripts = append(scripts, file)
+		} else if !entry.IsDir() && filepath.Ext(file) == ".lox" {
+			if source, err := readScript(file); err == nil && (bytes.Contains(source, []byte(expectPrefix)) || bytes.Contains(source, []byte(expectRuntimeErrorPrefix))) {
+				scripts = append(scripts, file)
+			}
+		}
+		return nil
+	})
+	return scripts, err
+}
+
+// runTestScript runs one test script in a fresh interpreter. It returns ""
+// if the script passed, or a description of how it failed.
+func (lox *Lox) runTestScript(path string) string {
+	source, err := readScript(path)
+	if err != nil {
+		return fmt.Sprintf("  %v\n", err)
+	}
+
+	var expected []string
+	expectedError := ""
+	timeLimit := lox.timeLimit
+	var options []Option
+	for _, line := range strings.Split(string(source), "\n") {
+		if _, expectation, found := strings.Cut(line, expectPrefix); found {
+			expected = append(expected, strings.TrimRight(expectation, " \r"))
+		} else if _, message, found := strings.Cut(line, expectRuntimeErrorPrefix); found {
+			expectedError = strings.TrimSpace(message)
+		} else if _, limit, found := strings.Cut(line, timeoutPrefix); found {
+			if timeLimit, err = time.ParseDuration(strings.TrimSpace(limit)); err != nil {
+				return fmt.Sprintf("  invalid timeout annotation: %v\n", err)
+			}
+		} else if _, depth, found := strings.Cut(line, maxCallDepthPrefix); found {
+			limit, err := strconv.Atoi(strings.TrimSpace(depth))
+			if err != nil {
+				return fmt.Sprintf("  invalid max-call-depth annotation: %v\n", err)
+			}
+			options = append(options, WithMaxCallDepth(limit))
+		} else if _, name, found := strings.Cut(line, optionPrefix); found {
+			option, ok := testOptions[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Sprintf("  unknown option annotation %q\n", strings.TrimSpace(name))
+			}
+			options = append(options, option)
+		}
+	}
+
+	statements, ok := load(string(source), lox)
+	if !ok {
+		return "  syntax errors\n"
+	}
+
+	var output bytes.Buffer
+	interpreter := lox.newInterpreter(append(options, WithOutput(&output), WithTimeLimit(timeLimit))...)
+	interpreter.enterScript(path)
+	_, err = interpreter.Interpret(statements)
+	interpreter.flushOutput()
+
+	var actual []string
+	if text := strings.TrimSuffix(strings.ReplaceAll(output.String(), "\r\n", "\n"), "\n"); text != "" {
+		actual = strings.Split(text, "\n")
+	}
+
+	var failure strings.Builder
+	if line := firstDifference(expected, actual); line != 0 {
+		fmt.Fprintf(&failure, "  output differs from expectations, first at output line %v:\n", line)
+		for _, diff := range strings.SplitAfter(unifiedDiff(expected, actual), "\n") {
+			if diff != "" {
+				failure.WriteString("    " + diff)
+			}
+		}
+	}
+	if err == nil && expectedError != "" {
+		fmt.Fprintf(&failure, "  expected runtime error %q, but the script finished\n", expectedError)
+	} else if err != nil && plainText(err.(*RuntimeError).message) != expectedError {
+		if expectedError != "" {
+			fmt.Fprintf(&failure, "  expected runtime error %q, got:\n", expectedError)
+		}
+		fmt.Fprintf(&failure, "  runtime error: %v", err)
+	}
+	return failure.String()
+}
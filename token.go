@@ -8,6 +8,7 @@ type Token struct {
 	lexeme    string      // Lexeme is the actual string value from the source code
 	literal   interface{} // Literal holds the actual value for literals (numbers, strings, etc.)
 	line      int         // Line indicates the line number where the token appears in source
+	column    int         // Column of the token's first character, starting at 1 (0 if unknown)
 }
 
 // NewToken returns a new Token instance.
@@ -0,0 +1,74 @@
+// Package main implements a Lox language interpreter
+package main
+
+// TokenStream gives tools that sit between the scanner and the parser
+// (preprocessors, macro expanders) a cursor over scanned tokens with
+// arbitrary lookahead. The stream always ends with an EOF token, which is
+// returned again for any read past the end.
+type TokenStream struct {
+	tokens   []*Token // Tokens being read, ending with EOF
+	position int      // Index of the next token to return
+}
+
+// NewTokenStream creates a stream over tokens, e.g. the result of
+// Scanner.ScanTokens. An EOF token is appended if tokens doesn't end in one.
+func NewTokenStream(tokens []*Token) *TokenStream {
+	if len(tokens) == 0 || tokens[len(tokens)-1].tokenType != EOF {
+		line := 1
+		if len(tokens) > 0 {
+			line = tokens[len(tokens)-1].line
+		}
+		tokens = append(tokens[:len(tokens):len(tokens)], NewToken(EOF, "", nil, line))
+	}
+	return &TokenStream{tokens: tokens}
+}
+
+// Peek returns the token n places ahead without consuming anything.
+// Peek(0) is the token the next call to Next will return.
+func (s *TokenStream) Peek(n int) *Token {
+	index := s.position + n
+	if n < 0 || index >= len(s.tokens) {
+		return s.tokens[len(s.tokens)-1]
+	}
+	return s.tokens[index]
+}
+
+// Next consumes and returns the next token.
+func (s *TokenStream) Next() *Token {
+	token := s.Peek(0)
+	if !s.AtEnd() {
+		s.position++
+	}
+	return token
+}
+
+// AtEnd reports whether only the EOF token is left.
+func (s *TokenStream) AtEnd() bool {
+	return s.Peek(0).tokenType == EOF
+}
+
+// Position returns the index of the next token, for use with Seek.
+func (s *TokenStream) Position() int {
+	return s.position
+}
+
+// Seek moves the stream back (or forward) to a position returned by Position.
+func (s *TokenStream) Seek(position int) {
+	s.position = max(0, min(position, len(s.tokens)-1))
+}
+
+// Line returns the source line of the next token.
+func (s *TokenStream) Line() int {
+	return s.Peek(0).line
+}
+
+// Column returns the source column of the next token.
+func (s *TokenStream) Column() int {
+	return s.Peek(0).column
+}
+
+// Rest returns the unconsumed tokens, including the final EOF, ready to be
+// handed to NewParser.
+func (s *TokenStream) Rest() []*Token {
+	return s.tokens[s.position:]
+}
@@ -9,6 +9,8 @@ const (
 	RIGHT_PAREN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
 	DOT
 	MINUS
@@ -16,6 +18,13 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	AT
+	COLON
+	QUESTION
+	AMPERSAND
+	PIPE
+	CARET
+	TILDE
 
 	// One or two character tokens
 	BANG
@@ -26,6 +35,11 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	LESS_LESS
+	GREATER_GREATER
+	STAR_STAR
+	ARROW
+	QUESTION_DOT
 
 	// Literals
 	IDENTIFIER
@@ -50,6 +64,20 @@ const (
 	VAR
 	WHILE
 	BREAK
+	REQUIRES
+	ENSURES
+	LOOP
+	USING
+	STATIC
+	CONST
+	CONTINUE
+	IMPORT
+	TRY
+	CATCH
+	FINALLY
+	THROW
+	MATCH
+	ENUM
 
 	EOF
 )
@@ -65,6 +93,10 @@ func (t TokenType) toString() string {
 		return "LEFT_BRACE"
 	case RIGHT_BRACE:
 		return "RIGHT_BRACE"
+	case LEFT_BRACKET:
+		return "LEFT_BRACKET"
+	case RIGHT_BRACKET:
+		return "RIGHT_BRACKET"
 	case COMMA:
 		return "COMMA"
 	case DOT:
@@ -79,6 +111,20 @@ func (t TokenType) toString() string {
 		return "SLASH"
 	case STAR:
 		return "STAR"
+	case AT:
+		return "AT"
+	case COLON:
+		return "COLON"
+	case QUESTION:
+		return "QUESTION"
+	case AMPERSAND:
+		return "AMPERSAND"
+	case PIPE:
+		return "PIPE"
+	case CARET:
+		return "CARET"
+	case TILDE:
+		return "TILDE"
 	case BANG:
 		return "BANG"
 	case BANG_EQUAL:
@@ -95,6 +141,16 @@ func (t TokenType) toString() string {
 		return "LESS"
 	case LESS_EQUAL:
 		return "LESS_EQUAL"
+	case LESS_LESS:
+		return "LESS_LESS"
+	case GREATER_GREATER:
+		return "GREATER_GREATER"
+	case STAR_STAR:
+		return "STAR_STAR"
+	case ARROW:
+		return "ARROW"
+	case QUESTION_DOT:
+		return "QUESTION_DOT"
 	case IDENTIFIER:
 		return "IDENTIFIER"
 	case STRING:
@@ -135,6 +191,34 @@ func (t TokenType) toString() string {
 		return "WHILE"
 	case BREAK:
 		return "BREAK"
+	case REQUIRES:
+		return "REQUIRES"
+	case ENSURES:
+		return "ENSURES"
+	case LOOP:
+		return "LOOP"
+	case USING:
+		return "USING"
+	case STATIC:
+		return "STATIC"
+	case CONST:
+		return "CONST"
+	case CONTINUE:
+		return "CONTINUE"
+	case IMPORT:
+		return "IMPORT"
+	case TRY:
+		return "TRY"
+	case CATCH:
+		return "CATCH"
+	case FINALLY:
+		return "FINALLY"
+	case THROW:
+		return "THROW"
+	case MATCH:
+		return "MATCH"
+	case ENUM:
+		return "ENUM"
 	case EOF:
 		return "EOF"
 	default:
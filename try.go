@@ -0,0 +1,169 @@
+// Package main implements a Lox language interpreter
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// errorClassSource declares the built-in Error class. Runtime errors are
+// caught as instances of it, and scripts can throw it or a subclass.
+const errorClassSource = `
+class Error {
+  init(message) {
+    this.message = message;
+    this.line = nil;
+    this.type = nil;
+    this.stack = nil;
+  }
+}`
+
+// errorClassDeclaration parses errorClassSource once for every interpreter.
+var errorClassDeclaration = sync.OnceValue(func() *ClassStmt {
+	return NewParser(NewScanner(errorClassSource, nil).ScanTokens()).Parse()[0].(*ClassStmt)
+})
+
+// defineErrorClass adds the Error class. An Error has a 'message', and
+// once it's thrown or caught, the 'line' it came from, its 'type' and its
+// 'stack'. The type is the name of its class, or "RuntimeError" for errors
+// the interpreter raised. The stack has a line per call in progress,
+// innermost first, e.g. "in f at line 3" and "called from script at line 7".
+func defineErrorClass(globals *Environment) {
+	methods := make(map[string]*LoxFunction)
+	for _, method := range errorClassDeclaration().methods {
+		methods[method.name.lexeme] = NewLoxFunction(method, globals, method.name.lexeme == "init")
+	}
+	class := NewLoxClass("Error", nil, methods)
+	class.builtinError = true
+	globals.define("Error", class)
+}
+
+// isError reports whether value is an instance of Error or a subclass.
+func (i *Interpreter) isError(value interface{}) bool {
+	return isInstanceOf(value, i.natives.values["Error"].(*LoxClass))
+}
+
+// VisitThrowStmt runs 'throw value;', which stops the script with a
+// runtime error unless a catch block catches it and gets value back. An
+// Error that doesn't have a line yet gets the line of the throw.
+func (i *Interpreter) VisitThrowStmt(stmt *ThrowStmt) interface{} {
+	value := i.evaluate(stmt.value)
+	if value == nil {
+		panic(NewRuntimeErrorAt(stmt.keyword, "Can't throw nil."))
+	}
+
+	message := stringify(nil, value)
+	if i.isError(value) {
+		instance := value.(*LoxInstance)
+		message = instance.class.name
+		if text, ok := instance.fields["message"]; ok && text != nil {
+			message = stringify(nil, text)
+		}
+		if instance.fields["line"] == nil {
+			instance.fields["line"] = float64(stmt.keyword.line)
+		}
+	}
+	err := NewRuntimeErrorAt(stmt.keyword, message)
+	err.thrown = value
+	panic(err)
+}
+
+// caughtValue is the value a catch block gets for err: the thrown value,
+// or an Error with the message and line of an error the interpreter raised.
+// A thrown Error gets the type and stack it doesn't have yet.
+func (i *Interpreter) caughtValue(err *RuntimeError) interface{} {
+	if err.thrown != nil {
+		if i.isError(err.thrown) {
+			instance := err.thrown.(*LoxInstance)
+			if instance.fields["type"] == nil {
+				instance.fields["type"] = instance.class.name
+			}
+			if instance.fields["stack"] == nil {
+				instance.fields["stack"] = stackSummary(err)
+			}
+		}
+		return err.thrown
+	}
+	instance := NewLoxInstance(i.natives.values["Error"].(*LoxClass))
+	instance.fields["message"] = plainText(err.message)
+	instance.fields["line"] = nil
+	if err.line != LINE_UNKNOWN {
+		instance.fields["line"] = float64(err.line)
+	}
+	instance.fields["type"] = "RuntimeError"
+	instance.fields["stack"] = stackSummary(err)
+	return instance
+}
+
+// stackSummary is the stack trace of err as an Error's 'stack': one
+// unindented line per frame. An error outside any function still says
+// which line of the script it came from.
+func stackSummary(err *RuntimeError) string {
+	trace := err.stack
+	if len(trace) == 0 && err.line != LINE_UNKNOWN {
+		trace = []StackFrame{{function: "script", line: err.line}}
+	}
+	lines := strings.Split(strings.TrimSuffix(formatStackTrace(trace), "\n"), "\n")
+	for index, line := range lines {
+		lines[index] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// VisitTryStmt runs 'try { ... } catch (name) { ... } finally { ... }'.
+// A runtime error in the try block runs the catch block with the caught
+// value in name. The finally block runs however the others end: normally,
+// through return, break or continue, or with an error that wasn't caught,
+// which is raised again afterwards. A return or continue in the finally
+// block replaces the way the others ended. Interruptions such as a time
+// limit skip both blocks.
+func (i *Interpreter) VisitTryStmt(stmt *TryStmt) interface{} {
+	if stmt.finallyBody == nil {
+		return i.tryCatch(stmt)
+	}
+
+	var result interface{}
+	abrupt := i.catchAbrupt(func() { result = i.tryCatch(stmt) })
+	switch signal := i.execute(stmt.finallyBody).(type) {
+	case *ReturnError, *ContinueError:
+		return signal
+	}
+	if abrupt != nil {
+		panic(abrupt)
+	}
+	return result
+}
+
+// tryCatch runs the try block of stmt, and its catch block if the try
+// block stops with a runtime error.
+func (i *Interpreter) tryCatch(stmt *TryStmt) interface{} {
+	var result interface{}
+	err := i.catchRuntimeError(func() { result = i.execute(stmt.body) })
+	if err == nil {
+		return result
+	}
+	if stmt.catchBody == nil {
+		panic(err)
+	}
+
+	environment := NewEnclosingEnvironment(i.environment)
+	environment.define(stmt.catchName.lexeme, i.caughtValue(err))
+	return i.executeBlock([]Stmt{stmt.catchBody}, environment)
+}
+
+// catchAbrupt runs body and returns the break or runtime error it stops
+// with, or nil, so a finally block can run before it's raised again.
+func (i *Interpreter) catchAbrupt(body func()) (abrupt interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*BreakError); !ok {
+				panic(r) // re-panic if it's not a break
+			}
+			abrupt = r
+		}
+	}()
+	if err := i.catchRuntimeError(body); err != nil {
+		return err
+	}
+	return nil
+}
@@ -26,23 +26,48 @@ func main() {
 		"Assign : *Token name, Expr value",
 		"Binary : Expr left, *Token operator, Expr right",
 		"Call : Expr callee, *Token paren, []Expr arguments",
+		"Conditional : Expr condition, Expr thenBranch, Expr elseBranch",
+		"Index : Expr object, *Token bracket, Expr index",
+		"IndexSet : Expr object, *Token bracket, Expr index, Expr value",
+		"Slice : Expr object, *Token bracket, Expr start, Expr end",
+		"List : *Token bracket, []Expr elements",
+		"Dbg : *CallExpr call, string source",
+		"Function : *FunctionStmt declaration",
+		"Map : *Token brace, []Expr keys, []Expr values",
+		"Get : Expr object, *Token name, bool optional",
+		"OptionalChain : Expr expression",
+		"Match : *Token keyword, Expr subject, []*MatchArm arms",
 		"Grouping : Expr expression",
 		"Literal : interface{} value",
 		"Logical : Expr left, *Token operator, Expr right",
+		"Loop : *Token keyword, Stmt body, *Token label",
+		"Set : Expr object, *Token name, Expr value",
+		"Super : *Token keyword, *Token method",
+		"This : *Token keyword",
 		"Unary : *Token operator, Expr right",
 		"Variable : *Token name",
 	})
 
 	defineAst(outputDir, "Stmt", []string{
-		"Block : []Stmt statements",
+		"Block : []Stmt statements, bool scoped, *numericFor numericFor",
+		"Class : *Token name, *VariableExpr superclass, []*FunctionStmt methods, []*FunctionStmt staticMethods",
 		"Expression : Expr expression",
-		"Function : *Token name, []*Token params, []Stmt body",
+		"Function : *Token name, []*Token params, []Stmt body, []Expr requires, []Expr ensures, bool isGetter",
 		"If : Expr condition, Stmt thenBranch, Stmt elseBranch",
 		"Print : Expr expression",
 		"Return : *Token keyword, Expr value",
-		"Var : *Token name, Expr initializer",
-		"While : Expr condition, Stmt body",
-		"Break : ", // no values stored
+		"Var : *Token name, Expr initializer, bool constant",
+		"Enum : *Token name, []*Token members",
+		"Try : *Token keyword, Stmt body, *Token catchName, Stmt catchBody, Stmt finallyBody",
+		"Throw : *Token keyword, Expr value",
+		"Destructure : *Token pattern, []*Token names, *Token equals, Expr initializer",
+		"While : *Token keyword, Expr condition, Stmt body, Expr increment, *Token label",
+		"ForIn : *Token keyword, *Token name, Expr iterable, Stmt body, *Token label",
+		"Using : *Token keyword, *Token name, Expr initializer, Stmt body",
+		"Break : *Token keyword, *Token label, Expr value",
+		"Continue : *Token keyword, *Token label",
+		"Import : *Token keyword, *Token path",
+		"ImportNative : *Token keyword, *Token path",
 	})
 }
 